@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+var (
+	browser     = tview.NewFlex()
+	browserForm = tview.NewForm()
+	repoList    = tview.NewList()
+	tagList     = tview.NewList()
+)
+
+// Page to browse the repositories and tags of the private registry. The
+// registry defaults to the target entered in the push menu.
+func registryBrowser(i *Images) {
+
+	server, username, password := i.target.server, i.target.username, i.target.password
+	repo := ""
+	reg := newRegistryClient(server, username, password)
+
+	browserForm.Clear(true)
+	browserForm.SetBorder(true).
+		SetTitle(" Registry Browser ").
+		SetTitleColor(tcell.ColorGreen)
+
+	repoList.Clear().ShowSecondaryText(false).
+		SetBorder(true).
+		SetTitle(" Repositories ")
+	tagList.Clear().ShowSecondaryText(false).
+		SetBorder(true).
+		SetTitle(" Tags ")
+
+	browserForm.AddInputField("Server Address: ", server, 40, nil, func(s string) {
+		server = s
+	}).AddInputField("Username: ", username, 40, nil, func(s string) {
+		username = s
+	}).AddPasswordField("Password: ", password, 40, 42, func(s string) {
+		password = s
+	}).AddInputField("Repository: ", "", 40, nil, func(s string) {
+		repo = s
+	}).AddButton("Return to Tools", func() {
+		showTools()
+	}).AddButton("List Repositories", func() {
+		reg = newRegistryClient(server, username, password)
+		go loadRepositories(reg)
+	}).AddButton("List Tags", func() {
+		reg = newRegistryClient(server, username, password)
+		go loadTags(reg, repo)
+	})
+
+	repoList.SetSelectedFunc(func(index int, name string, secondary string, shortcut rune) {
+		go loadTags(reg, name)
+	})
+	repoList.SetDoneFunc(func() {
+		app.SetFocus(browserForm)
+	})
+	tagList.SetDoneFunc(func() {
+		app.SetFocus(repoList)
+	})
+
+	lists := tview.NewFlex().
+		AddItem(repoList, 0, 1, false).
+		AddItem(tagList, 0, 1, false)
+
+	browser.Clear().
+		SetDirection(tview.FlexRow).
+		AddItem(browserForm, 0, 1, true).
+		AddItem(lists, 0, 2, false)
+
+	pages.AddAndSwitchToPage("Registry", browser, true)
+	app.SetFocus(browserForm)
+	setText("Enter the registry to browse. Repositories can only be listed when the registry allows the _catalog endpoint, otherwise enter a repository and list its tags.", "white")
+}
+
+func loadRepositories(reg *registryClient) {
+
+	repos, err := reg.catalog()
+	if err != nil {
+		ErrorLogger.Println(err)
+		handlePanic(err)
+		return
+	}
+	sort.Strings(repos)
+
+	app.QueueUpdateDraw(func() {
+		repoList.Clear()
+		for _, r := range repos {
+			repoList.AddItem(r, "", 0, nil)
+		}
+		app.SetFocus(repoList)
+	})
+	setText(fmt.Sprintf("Found %d repositories in %s", len(repos), reg.server), "white")
+}
+
+func loadTags(reg *registryClient, repo string) {
+
+	if repo == "" {
+		setText("Please select or enter a repository.", "red")
+		return
+	}
+
+	tags, err := reg.tags(repo)
+	if err != nil {
+		ErrorLogger.Println(err)
+		handlePanic(err)
+		return
+	}
+	sort.Strings(tags)
+
+	app.QueueUpdateDraw(func() {
+		tagList.Clear().SetTitle(" Tags: " + repo + " ")
+		for _, t := range tags {
+			tagList.AddItem(t, "", 0, nil)
+		}
+		tagList.SetSelectedFunc(func(index int, tag string, secondary string, shortcut rune) {
+			go showManifest(reg, repo, tag)
+		})
+		app.SetFocus(tagList)
+	})
+	setText(fmt.Sprintf("Found %d tags in %s", len(tags), repo), "white")
+}
+
+// Prints the digest, size, platforms and creation time of a tag.
+func showManifest(reg *registryClient, repo string, tag string) {
+
+	m, err := reg.getManifest(repo, tag)
+	if err != nil {
+		ErrorLogger.Println(err)
+		handlePanic(err)
+		return
+	}
+
+	lines := []string{
+		"Image:      " + reg.server + "/" + repo + ":" + tag,
+		"Digest:     " + m.digest,
+		"Media type: " + m.MediaType,
+	}
+
+	// An index has one image manifest per platform.
+	images := []*manifest{m}
+	if m.isIndex() {
+		images = nil
+		for _, d := range m.Manifests {
+			child, err := reg.getManifest(repo, d.Digest)
+			if err != nil {
+				ErrorLogger.Println(err)
+				handlePanic(err)
+				return
+			}
+			images = append(images, child)
+		}
+	}
+
+	for _, im := range images {
+		size := im.Config.Size
+		for _, l := range im.Layers {
+			size += l.Size
+		}
+
+		c, err := reg.getConfig(repo, im.Config.Digest)
+		if err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+			return
+		}
+		p := c.OS + "/" + c.Architecture
+		if c.Variant != "" {
+			p += "/" + c.Variant
+		}
+
+		lines = append(lines, "",
+			"Platform:   "+p,
+			"Manifest:   "+im.digest,
+			fmt.Sprintf("Size:       %.1f MB (%d layers)", float64(size)/1e6, len(im.Layers)),
+			"Created:    "+c.Created.Format("2006-01-02 15:04:05 MST"))
+	}
+
+	setText(strings.Join(lines, "\n"), "white")
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	REDACTED                = "REDACTED"
+	LAST_APPLIED_ANNOTATION = "kubectl.kubernetes.io/last-applied-configuration"
+)
+
+// Keys and env var names whose values are left out of the bundle. Only names
+// ending in a sensitive word match, references such as secretName are kept.
+var sensitiveName = regexp.MustCompile(`(?i)(password|passwd|(^|_)pass|secret|secret_?key(_?base)?|token|credentials?|api_?key|access_?key|private_?key|auth)$`)
+
+// Files are added to the archive until the size limit is reached, the ones
+// that did not fit are listed in skipped.txt.
+type supportBundle struct {
+	tw      *tar.Writer
+	prefix  string
+	written int64
+	limit   int64
+	skipped []string
+}
+
+func (b *supportBundle) add(name string, data []byte) {
+
+	if b.written+int64(len(data)) > b.limit {
+		b.skipped = append(b.skipped, fmt.Sprintf("%s (%d bytes)", name, len(data)))
+		return
+	}
+	if err := addBytesToArchive(b.tw, b.prefix+name, data); err != nil {
+		ErrorLogger.Println(err)
+		b.skipped = append(b.skipped, name+": "+err.Error())
+		return
+	}
+	b.written += int64(len(data))
+}
+
+// Adds obj as YAML with secret values redacted.
+func (b *supportBundle) addYAML(name string, obj interface{}) {
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		b.addError(name, err)
+		return
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		b.addError(name, err)
+		return
+	}
+	out, err := yaml.Marshal(redact(generic))
+	if err != nil {
+		b.addError(name, err)
+		return
+	}
+	b.add(name, out)
+}
+
+func (b *supportBundle) addFile(name string, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		b.addError(name, err)
+		return
+	}
+	b.add(name, data)
+}
+
+// Errors are written next to the file that could not be collected.
+func (b *supportBundle) addError(name string, err error) {
+	ErrorLogger.Println(name, err)
+	b.add(name+".error", []byte(err.Error()+"\n"))
+}
+
+// Replaces secret values in a decoded JSON object. Secret data, values of
+// sensitive keys, env vars with sensitive names and the last applied
+// configuration, which repeats the whole object in clear text, are redacted.
+func redact(obj interface{}) interface{} {
+
+	switch o := obj.(type) {
+	case map[string]interface{}:
+		if metadata, ok := o["metadata"].(map[string]interface{}); ok {
+			if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+				if _, ok := annotations[LAST_APPLIED_ANNOTATION]; ok {
+					annotations[LAST_APPLIED_ANNOTATION] = REDACTED
+				}
+			}
+		}
+		if o["kind"] == "Secret" {
+			for _, field := range []string{"data", "stringData"} {
+				if data, ok := o[field].(map[string]interface{}); ok {
+					for k := range data {
+						data[k] = REDACTED
+					}
+				}
+			}
+		}
+		if name, ok := o["name"].(string); ok && sensitiveName.MatchString(name) {
+			if _, ok := o["value"].(string); ok {
+				o["value"] = REDACTED
+			}
+		}
+		for k, v := range o {
+			if _, ok := v.(string); ok && sensitiveName.MatchString(k) {
+				o[k] = REDACTED
+				continue
+			}
+			o[k] = redact(v)
+		}
+	case []interface{}:
+		for n, v := range o {
+			o[n] = redact(v)
+		}
+	}
+	return obj
+}
+
+// Collects the diagnostics of the cnvrg namespaces into a timestamped tar.gz.
+func (v *Versions) collectBundle(namespaces []string, imagesFile string, logLines int64, limit int64) {
+	InfoLogger.Println("In the collectBundle function")
+
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	stamp := time.Now().Format("20060102-150405")
+	fileName := "support-bundle-" + stamp + ".tar.gz"
+
+	out, err := os.Create(fileName)
+	if err != nil {
+		panic(err)
+	}
+	defer out.Close()
+	gw := gzip.NewWriter(out)
+	defer gw.Close()
+	tw := tar.NewWriter(gw)
+	defer tw.Close()
+
+	b := &supportBundle{tw: tw, prefix: "support-bundle-" + stamp + "/", limit: limit}
+
+	setText("Collecting cluster information...", "white")
+	if info, err := v.clientset.Discovery().ServerVersion(); err != nil {
+		b.addError("cluster/version.yaml", err)
+	} else {
+		b.addYAML("cluster/version.yaml", info)
+	}
+	if nodes, err := v.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err != nil {
+		b.addError("cluster/nodes.yaml", err)
+	} else {
+		b.addYAML("cluster/nodes.yaml", nodes)
+	}
+
+	for _, ns := range namespaces {
+		setText("Collecting namespace "+ns+"...", "white")
+		dir := "namespaces/" + ns + "/"
+
+		if list, err := v.clientset.AppsV1().Deployments(ns).List(ctx, metav1.ListOptions{}); err != nil {
+			b.addError(dir+"deployments.yaml", err)
+		} else {
+			b.addYAML(dir+"deployments.yaml", list)
+		}
+		if list, err := v.clientset.AppsV1().StatefulSets(ns).List(ctx, metav1.ListOptions{}); err != nil {
+			b.addError(dir+"statefulsets.yaml", err)
+		} else {
+			b.addYAML(dir+"statefulsets.yaml", list)
+		}
+		if list, err := v.clientset.CoreV1().Events(ns).List(ctx, metav1.ListOptions{}); err != nil {
+			b.addError(dir+"events.yaml", err)
+		} else {
+			b.addYAML(dir+"events.yaml", list)
+		}
+		if list, err := v.clientset.CoreV1().Secrets(ns).List(ctx, metav1.ListOptions{}); err != nil {
+			b.addError(dir+"secrets.yaml", err)
+		} else {
+			for n := range list.Items {
+				list.Items[n].Kind = "Secret"
+			}
+			b.addYAML(dir+"secrets.yaml", list)
+		}
+
+		pods, err := v.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			b.addError(dir+"pods.yaml", err)
+			continue
+		}
+		b.addYAML(dir+"pods.yaml", pods)
+		for _, p := range pods.Items {
+			v.addPodLogs(b, dir+"logs/", p, logLines)
+		}
+	}
+
+	setText("Collecting cnvrg custom resources...", "white")
+	v.addCustomResources(b)
+
+	b.addFile("tool/logs.txt", LOG_FILE_PATH)
+	if imagesFile != "" {
+		b.addFile("tool/images.txt", imagesFile)
+	}
+	if len(b.skipped) > 0 {
+		if err := addBytesToArchive(tw, b.prefix+"skipped.txt", []byte(strings.Join(b.skipped, "\n")+"\n")); err != nil {
+			panic(err)
+		}
+	}
+
+	message := fmt.Sprintf("Support bundle written to %s (%.1f MB uncompressed)", fileName, float64(b.written)/1e6)
+	if len(b.skipped) > 0 {
+		message += fmt.Sprintf("\n%d files were left out because of the size limit or errors, see skipped.txt", len(b.skipped))
+	}
+	setText(message, "green")
+}
+
+// Adds the last lines of every container of the pod, and of the previous
+// container when it restarted.
+func (v *Versions) addPodLogs(b *supportBundle, dir string, p corev1.Pod, lines int64) {
+
+	limitBytes := int64(1024 * 1024)
+	statuses := append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+
+	for _, c := range statuses {
+		previous := []bool{false}
+		if c.RestartCount > 0 {
+			previous = append(previous, true)
+		}
+		for _, prev := range previous {
+			name := dir + p.Name + "/" + c.Name + ".log"
+			if prev {
+				name = dir + p.Name + "/" + c.Name + ".previous.log"
+			}
+			opts := &corev1.PodLogOptions{Container: c.Name, Previous: prev, TailLines: &lines, LimitBytes: &limitBytes}
+			stream, err := v.clientset.CoreV1().Pods(p.Namespace).GetLogs(p.Name, opts).Stream(ctx)
+			if err != nil {
+				b.addError(name, err)
+				continue
+			}
+			data, err := io.ReadAll(stream)
+			stream.Close()
+			if err != nil {
+				b.addError(name, err)
+				continue
+			}
+			b.add(name, data)
+		}
+	}
+}
+
+// Adds every instance of the cnvrg custom resource definitions.
+func (v *Versions) addCustomResources(b *supportBundle) {
+
+	dyn, err := v.dynamicClient()
+	if err != nil {
+		b.addError("crs/crds.yaml", err)
+		return
+	}
+	crds, err := v.cnvrgCRDs(dyn)
+	if err != nil {
+		b.addError("crs/crds.yaml", err)
+		return
+	}
+
+	for _, crd := range crds {
+		name := "crs/" + crd.GetName() + ".yaml"
+		items, err := listCustomResources(dyn, crd)
+		if err != nil {
+			b.addError(name, err)
+			continue
+		}
+		var objects []interface{}
+		for _, item := range items {
+			objects = append(objects, item.Object)
+		}
+		b.addYAML(name, objects)
+	}
+}
+
+// Form for the namespaces to collect and the limits of the bundle.
+func bundleMenu(i *Images) {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	namespaces := strings.Join(v.namespaces(), ", ")
+	logLines, limitMB := "500", "100"
+
+	f := showToolForm("Support Bundle")
+	f.AddInputField("Namespaces: ", namespaces, 40, nil, func(s string) {
+		namespaces = s
+	}).AddInputField("Log Lines: ", logLines, 10, nil, func(s string) {
+		logLines = s
+	}).AddInputField("Size Limit (MB): ", limitMB, 10, nil, func(s string) {
+		limitMB = s
+	}).AddButton("Collect", func() {
+		lines, err := strconv.ParseInt(logLines, 10, 64)
+		if err != nil {
+			setText("Log Lines must be a number.", "red")
+			return
+		}
+		mb, err := strconv.ParseInt(limitMB, 10, 64)
+		if err != nil {
+			setText("Size Limit must be a number.", "red")
+			return
+		}
+		go v.collectBundle(splitList(namespaces), i.fileName, lines, mb*1024*1024)
+	})
+
+	setText("Collects cluster version, nodes, workloads, pods, events, container logs, cnvrg custom resources, logs.txt and the images file. Secret values are redacted.", "white")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRedact(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		object string
+		path   []string
+		want   interface{}
+	}{
+		{
+			name:   "last applied configuration",
+			object: `{"kind":"Deployment","metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{\"password\":\"hunter2\"}","team":"cnvrg"}}}`,
+			path:   []string{"metadata", "annotations", LAST_APPLIED_ANNOTATION},
+			want:   REDACTED,
+		},
+		{
+			name:   "other annotations are kept",
+			object: `{"kind":"Deployment","metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{}","team":"cnvrg"}}}`,
+			path:   []string{"metadata", "annotations", "team"},
+			want:   "cnvrg",
+		},
+		{
+			name:   "env password",
+			object: `{"kind":"Deployment","spec":{"env":[{"name":"CNVRG_PASSWORD","value":"hunter2"}]}}`,
+			path:   []string{"spec", "env"},
+			want:   []interface{}{map[string]interface{}{"name": "CNVRG_PASSWORD", "value": REDACTED}},
+		},
+		{
+			name:   "env without secrets",
+			object: `{"kind":"Deployment","spec":{"env":[{"name":"CNVRG_SECRET_NAME","value":"registry"}]}}`,
+			path:   []string{"spec", "env"},
+			want:   []interface{}{map[string]interface{}{"name": "CNVRG_SECRET_NAME", "value": "registry"}},
+		},
+		{
+			name:   "secret data",
+			object: `{"kind":"Secret","data":{"password":"aHVudGVyMg=="},"stringData":{"user":"admin"}}`,
+			path:   []string{"stringData", "user"},
+			want:   REDACTED,
+		},
+		{
+			name:   "secret last applied configuration",
+			object: `{"kind":"Secret","metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{\"data\":{}}"}},"data":{"password":"aHVudGVyMg=="}}`,
+			path:   []string{"metadata", "annotations", LAST_APPLIED_ANNOTATION},
+			want:   REDACTED,
+		},
+		{
+			name:   "volume secret name",
+			object: `{"kind":"Deployment","spec":{"volumes":[{"name":"tls","secret":{"secretName":"cnvrg-tls"}}]}}`,
+			path:   []string{"spec", "volumes"},
+			want:   []interface{}{map[string]interface{}{"name": "tls", "secret": map[string]interface{}{"secretName": "cnvrg-tls"}}},
+		},
+		{
+			name:   "registry password",
+			object: `{"kind":"CnvrgApp","spec":{"registry":{"user":"admin","password":"hunter2"}}}`,
+			path:   []string{"spec", "registry", "password"},
+			want:   REDACTED,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var obj map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.object), &obj); err != nil {
+				t.Fatal(err)
+			}
+			redact(obj)
+			got, found, err := unstructured.NestedFieldNoCopy(obj, tt.path...)
+			if err != nil || !found {
+				t.Fatalf("field %v not found: %v", tt.path, err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("got %s, want %s", gotJSON, wantJSON)
+			}
+		})
+	}
+}
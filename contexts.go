@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+var (
+	contextPage = tview.NewFlex()
+	contextForm = tview.NewForm()
+	contextList = tview.NewList()
+)
+
+// Page listing the contexts of the loaded kube configs. Selecting a context
+// switches the tool to that cluster.
+func contextMenu() {
+
+	path := ""
+
+	contextForm.Clear(true)
+	contextForm.SetBorder(true).
+		SetTitle(" Kube Contexts ").
+		SetTitleAlign(tview.AlignCenter).
+		SetTitleColor(tcell.ColorGreen)
+
+	contextForm.AddInputField("Kubeconfig File: ", path, 40, nil, func(s string) {
+		path = s
+	}).AddButton("Return to Tools", func() {
+		showTools()
+	}).AddButton("Load Kubeconfig", func() {
+		if err := addKubeconfig(path); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+			return
+		}
+		refreshContexts()
+		setText("Loaded "+path, "green")
+	})
+
+	contextList.SetBorder(true).
+		SetTitle(" Contexts ")
+	contextList.SetSelectedFunc(func(index int, name string, secondary string, shortcut rune) {
+		setText("Switching to context "+name, "white")
+		if err := useContext(name); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+			return
+		}
+		refreshContexts()
+		setText("Using context "+name, "green")
+	})
+	contextList.SetDoneFunc(func() {
+		app.SetFocus(contextForm)
+	})
+
+	contextPage.Clear().
+		SetDirection(tview.FlexRow).
+		AddItem(contextForm, 7, 0, false).
+		AddItem(contextList, 0, 1, true)
+
+	refreshContexts()
+	pages.AddAndSwitchToPage("Contexts", contextPage, true)
+	app.SetFocus(contextList)
+	setText("Select a context to switch clusters, or load another kubeconfig file to add its contexts.", "white")
+}
+
+func refreshContexts() {
+
+	raw, err := kubeconfig.RawConfig()
+	if err != nil {
+		ErrorLogger.Println(err)
+		handlePanic(err)
+		return
+	}
+
+	var names []string
+	for name := range raw.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	current := currentContext()
+	contextList.Clear()
+	for _, name := range names {
+		c := raw.Contexts[name]
+		secondary := fmt.Sprintf("cluster: %s  user: %s  namespace: %s", c.Cluster, c.AuthInfo, c.Namespace)
+		if name == current {
+			secondary = "(current) " + secondary
+		}
+		contextList.AddItem(name, secondary, 0, nil)
+		if name == current {
+			contextList.SetCurrentItem(contextList.GetItemCount() - 1)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var crdResource = schema.GroupVersionResource{
+	Group:    "apiextensions.k8s.io",
+	Version:  "v1",
+	Resource: "customresourcedefinitions",
+}
+
+// The dynamic client is built from the kube config in use, so it follows the
+// context selected in the UI.
+func (v *Versions) dynamicClient() (dynamic.Interface, error) {
+	config, err := kubeconfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfig(config)
+}
+
+// Returns the custom resource definitions of the cnvrg API groups.
+func (v *Versions) cnvrgCRDs(dyn dynamic.Interface) ([]unstructured.Unstructured, error) {
+
+	list, err := dyn.Resource(crdResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var crds []unstructured.Unstructured
+	for _, crd := range list.Items {
+		group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+		if strings.Contains(group, "cnvrg") {
+			crds = append(crds, crd)
+		}
+	}
+	return crds, nil
+}
+
+// Returns the resource of a CRD at its storage version and whether it is
+// namespaced.
+func crdGVR(crd unstructured.Unstructured) (schema.GroupVersionResource, bool) {
+
+	group, _, _ := unstructured.NestedString(crd.Object, "spec", "group")
+	plural, _, _ := unstructured.NestedString(crd.Object, "spec", "names", "plural")
+	scope, _, _ := unstructured.NestedString(crd.Object, "spec", "scope")
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+
+	version := ""
+	for _, obj := range versions {
+		ver, _ := obj.(map[string]interface{})
+		name, _ := ver["name"].(string)
+		if storage, _ := ver["storage"].(bool); storage || version == "" {
+			version = name
+		}
+	}
+
+	return schema.GroupVersionResource{Group: group, Version: version, Resource: plural}, scope == "Namespaced"
+}
+
+// Lists the custom resources of a CRD in every namespace.
+func listCustomResources(dyn dynamic.Interface, crd unstructured.Unstructured) ([]unstructured.Unstructured, error) {
+
+	gvr, _ := crdGVR(crd)
+	list, err := dyn.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/kube-openapi/pkg/validation/strfmt"
+	"k8s.io/kube-openapi/pkg/validation/validate"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	crPage     = tview.NewFlex()
+	crForm     = tview.NewForm()
+	crTable    = tview.NewTable()
+	crEditPage = tview.NewFlex()
+	crEditForm = tview.NewForm()
+	crEditor   = tview.NewTextArea()
+	crHeaders  = []string{"KIND", "NAMESPACE", "NAME", "AGE"}
+)
+
+// An instance of a cnvrg custom resource and the definition it belongs to.
+type customResource struct {
+	crd unstructured.Unstructured
+	obj unstructured.Unstructured
+}
+
+func (r *customResource) resource(dyn dynamic.Interface) dynamic.ResourceInterface {
+	gvr, namespaced := crdGVR(r.crd)
+	if namespaced {
+		return dyn.Resource(gvr).Namespace(r.obj.GetNamespace())
+	}
+	return dyn.Resource(gvr)
+}
+
+// Returns the object as YAML without the managed fields, which are only noise
+// when editing.
+func (r *customResource) yaml() (string, error) {
+	obj := r.obj.DeepCopy()
+	obj.SetManagedFields(nil)
+	out, err := yaml.Marshal(obj.Object)
+	return string(out), err
+}
+
+// Returns the OpenAPI schema of the version the resource is served at.
+func (r *customResource) schema() (*spec.Schema, error) {
+
+	gvr, _ := crdGVR(r.crd)
+	versions, _, _ := unstructured.NestedSlice(r.crd.Object, "spec", "versions")
+	for _, obj := range versions {
+		ver, _ := obj.(map[string]interface{})
+		if ver["name"] != gvr.Version {
+			continue
+		}
+		raw, found, _ := unstructured.NestedMap(ver, "schema", "openAPIV3Schema")
+		if !found {
+			return nil, nil
+		}
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		s := &spec.Schema{}
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return nil, nil
+}
+
+// Parses the edited YAML and validates it against the schema of the CRD. The
+// identity of the object can not be changed.
+func (r *customResource) parse(edited string) (*unstructured.Unstructured, error) {
+
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(edited), &obj.Object); err != nil {
+		return nil, err
+	}
+	if obj.Object == nil {
+		return nil, fmt.Errorf("the resource is empty")
+	}
+	if obj.GetAPIVersion() != r.obj.GetAPIVersion() || obj.GetKind() != r.obj.GetKind() ||
+		obj.GetName() != r.obj.GetName() || obj.GetNamespace() != r.obj.GetNamespace() {
+		return nil, fmt.Errorf("apiVersion, kind, name and namespace can not be changed")
+	}
+
+	schema, err := r.schema()
+	if err != nil {
+		return nil, err
+	}
+	if schema != nil {
+		if err := validate.AgainstSchema(schema, obj.Object, strfmt.Default); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// Lists the instances of every cnvrg CRD.
+func (v *Versions) customResources(dyn dynamic.Interface) ([]*customResource, error) {
+
+	crds, err := v.cnvrgCRDs(dyn)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*customResource
+	for _, crd := range crds {
+		items, err := listCustomResources(dyn, crd)
+		if err != nil {
+			ErrorLogger.Println(crd.GetName(), err)
+			continue
+		}
+		for _, item := range items {
+			result = append(result, &customResource{crd: crd, obj: item})
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		ka := result[a].obj.GetKind() + "/" + result[a].obj.GetNamespace() + "/" + result[a].obj.GetName()
+		kb := result[b].obj.GetKind() + "/" + result[b].obj.GetNamespace() + "/" + result[b].obj.GetName()
+		return ka < kb
+	})
+	return result, nil
+}
+
+// Prints the spec and status of the resource.
+func showCustomResource(r *customResource) {
+
+	var lines []string
+	for _, field := range []string{"spec", "status"} {
+		value, found, _ := unstructured.NestedFieldNoCopy(r.obj.Object, field)
+		if !found {
+			continue
+		}
+		out, err := yaml.Marshal(map[string]interface{}{field: value})
+		if err != nil {
+			handlePanic(err)
+			return
+		}
+		lines = append(lines, string(out))
+	}
+	setText(r.obj.GetKind()+" "+r.obj.GetNamespace()+"/"+r.obj.GetName()+"\n\n"+strings.Join(lines, "\n"), "white")
+}
+
+// Page listing the instances of the cnvrg custom resources.
+func customResourcesMenu() {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	dyn, err := v.dynamicClient()
+	if err != nil {
+		handlePanic(err)
+		return
+	}
+
+	kind := "All"
+	var all, shown []*customResource
+
+	render := func() {
+		shown = nil
+		for _, r := range all {
+			if kind == "All" || r.obj.GetKind() == kind {
+				shown = append(shown, r)
+			}
+		}
+		crTable.Clear()
+		setTableHeader(crTable, crHeaders)
+		for n, r := range shown {
+			row := []string{r.obj.GetKind(), r.obj.GetNamespace(), r.obj.GetName(), formatAge(r.obj.GetCreationTimestamp().Time)}
+			for col, value := range row {
+				crTable.SetCell(n+1, col, tview.NewTableCell(value))
+			}
+		}
+		crTable.SetTitle(fmt.Sprintf(" cnvrg Custom Resources (%d) ", len(shown)))
+	}
+
+	load := func() {
+		all, err = v.customResources(dyn)
+		if err != nil {
+			handlePanic(err)
+			return
+		}
+		render()
+	}
+
+	load()
+	kinds := []string{"All"}
+	for _, r := range all {
+		if k := r.obj.GetKind(); kinds[len(kinds)-1] != k {
+			kinds = append(kinds, k)
+		}
+	}
+
+	crForm.Clear(true)
+	crForm.SetHorizontal(true).
+		SetBorder(true).
+		SetTitle(" cnvrg Custom Resources ").
+		SetTitleAlign(tview.AlignCenter).
+		SetTitleColor(tcell.ColorGreen)
+
+	crForm.AddDropDown("Kind: ", kinds, 0, func(option string, index int) {
+		kind = option
+		render()
+	}).AddButton("Return to Tools", func() {
+		showTools()
+	}).AddButton("Refresh", func() {
+		load()
+		app.SetFocus(crTable)
+	})
+
+	crTable.SetBorder(true)
+	crTable.SetFixed(1, 0).
+		SetSelectable(true, false).
+		SetDoneFunc(func(key tcell.Key) {
+			app.SetFocus(crForm)
+		}).
+		SetSelectionChangedFunc(func(row int, column int) {
+			if row > 0 && row <= len(shown) {
+				showCustomResource(shown[row-1])
+			}
+		}).
+		SetSelectedFunc(func(row int, column int) {
+			if row > 0 && row <= len(shown) {
+				editCustomResource(dyn, shown[row-1], func() {
+					load()
+					pages.SwitchToPage("CustomResources")
+					app.SetFocus(crTable)
+				})
+			}
+		})
+
+	crPage.Clear().
+		SetDirection(tview.FlexRow).
+		AddItem(crForm, 3, 0, false).
+		AddItem(crTable, 0, 1, true)
+
+	pages.AddAndSwitchToPage("CustomResources", crPage, true)
+	app.SetFocus(crTable)
+	setText("Moving through the table shows the spec and status of a resource, Enter opens it in the editor. Esc moves to the filters.", "white")
+}
+
+// Page editing a custom resource as YAML. The changes are validated against
+// the CRD schema and a server side dry run, and the diff is confirmed before
+// the update is submitted.
+func editCustomResource(dyn dynamic.Interface, r *customResource, done func()) {
+
+	original, err := r.yaml()
+	if err != nil {
+		handlePanic(err)
+		return
+	}
+	crEditor.SetText(original, false)
+
+	// Returns the edited object once it passed validation and the dry run
+	check := func() (*unstructured.Unstructured, []string, bool) {
+		obj, err := r.parse(crEditor.GetText())
+		if err != nil {
+			setText("Validation failed:\n"+err.Error(), "red")
+			return nil, nil, false
+		}
+		diff := diffContext(lineDiff(original, crEditor.GetText()), 3)
+		if len(diff) == 0 {
+			setText("There are no changes.", "white")
+			return nil, nil, false
+		}
+		if _, err := r.resource(dyn).Update(ctx, obj, metav1.UpdateOptions{DryRun: []string{metav1.DryRunAll}}); err != nil {
+			setText("The API server rejected the change:\n"+err.Error(), "red")
+			return nil, nil, false
+		}
+		return obj, diff, true
+	}
+
+	crEditForm.Clear(true)
+	crEditForm.SetHorizontal(true).
+		SetBorder(true).
+		SetTitle(" Edit " + r.obj.GetKind() + " " + r.obj.GetNamespace() + "/" + r.obj.GetName() + " ").
+		SetTitleAlign(tview.AlignCenter).
+		SetTitleColor(tcell.ColorGreen)
+
+	crEditForm.AddButton("Back", func() {
+		done()
+	}).AddButton("Validate", func() {
+		if _, diff, ok := check(); ok {
+			setText("The change is valid:\n"+strings.Join(diff, "\n"), "green")
+		}
+	}).AddButton("Update", func() {
+		obj, diff, ok := check()
+		if !ok {
+			return
+		}
+		setText(strings.Join(diff, "\n"), "white")
+		confirm("Update "+r.obj.GetKind()+" "+r.obj.GetName()+" with the changes shown below?", func() {
+			if _, err := r.resource(dyn).Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+				setText("Update failed:\n"+err.Error(), "red")
+				return
+			}
+			InfoLogger.Println("Updated", r.obj.GetKind(), r.obj.GetNamespace()+"/"+r.obj.GetName())
+			done()
+			setText(r.obj.GetKind()+" "+r.obj.GetName()+" updated.", "green")
+		})
+	}).AddButton("Revert", func() {
+		crEditor.SetText(original, false)
+	})
+
+	crEditor.SetBorder(true)
+	crEditor.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			app.SetFocus(crEditForm)
+			return nil
+		}
+		return event
+	})
+
+	crEditPage.Clear().
+		SetDirection(tview.FlexRow).
+		AddItem(crEditForm, 3, 0, false).
+		AddItem(crEditor, 0, 1, true)
+
+	pages.AddAndSwitchToPage("CustomResourceEditor", crEditPage, true)
+	app.SetFocus(crEditor)
+	setText("Edit the resource and press Esc to reach Validate and Update. A conflict means the resource changed since it was loaded, go Back to reload it.", "white")
+}
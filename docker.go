@@ -10,10 +10,12 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
 )
 
 var (
@@ -23,16 +25,31 @@ var (
 		"unix:///var/run/docker.sock",
 		"unix:///Users/bsoper/.docker/run/docker.sock",
 	}
+
+	DEFAULT_REWRITE = "{server}/{registry}/{image}"
 )
 
 type Images struct {
 	fileName string
-	registry string
-	tag      []string
 	username string
 	password string
 	server   string
 	imageId  []string
+	target   Target
+	targets  []*Target
+}
+
+// A registry the images are pushed to. Each target has its own credentials
+// and rewrite rule, source and tag hold the source to target mapping.
+type Target struct {
+	server   string
+	registry string
+	username string
+	password string
+	rewrite  string
+	source   []string
+	tag      []string
+	failed   map[string]string
 }
 
 type AuthConfig struct {
@@ -57,65 +74,140 @@ func initClient() *client.Client {
 	return nil
 }
 
+// Returns the targets added in the push menu, or the target currently in the
+// push form when none were added.
+func (i *Images) activeTargets() []*Target {
+	if len(i.targets) == 0 {
+		return []*Target{&i.target}
+	}
+	return i.targets
+}
+
+// Adds a copy of the target in the push form to the list of targets.
+func (i *Images) addTarget() {
+	t := i.target
+	t.source, t.tag, t.failed = nil, nil, nil
+	i.targets = append(i.targets, &t)
+
+	var names []string
+	for _, t := range i.targets {
+		names = append(names, t.name()+"  ("+t.rewrite+")")
+	}
+	setText("Push targets:\n"+strings.Join(names, "\n"), "white")
+}
+
+func (t *Target) name() string {
+	return t.server + "/" + t.registry
+}
+
+// Applies the rewrite rule of the target to a source image. The rule may use
+// {server}, {registry}, {image} (name:tag or name@digest), {name} and {tag}.
+func (t *Target) rewriteImage(v string) string {
+
+	splitString := strings.Split(v, "/")
+	image := splitString[len(splitString)-1]
+	name, _, _ := strings.Cut(image, "@")
+	name, tag, _ := strings.Cut(name, ":")
+	if tag == "" {
+		tag = "latest"
+	}
+
+	rule := t.rewrite
+	if rule == "" {
+		rule = DEFAULT_REWRITE
+	}
+	r := strings.NewReplacer("{server}", t.server, "{registry}", t.registry,
+		"{image}", image, "{name}", name, "{tag}", tag)
+	return r.Replace(rule)
+}
+
 // s []string is source images.
-// the string is the target which is pulled from registry input field.
-// TODO make more descriptive possibly more descriptive function names
+// Every source image is tagged for each target, a failing target does not stop
+// the others.
 func (i *Images) tagImages(s []string) {
 	InfoLogger.Printf("The value of the slice is: %v", s)
 
-	var target []string
+	var out []string
+	color := "white"
 
-	defer func() {
-		if err := recover(); err != nil {
-			log.Println(err)
-			handlePanic(err)
+	for _, t := range i.activeTargets() {
+		errs := t.tagImages(s)
+		for _, v := range t.tag {
+			out = append(out, "["+t.name()+"] "+v)
+		}
+		for _, err := range errs {
+			ErrorLogger.Println(err)
+			out = append(out, "["+t.name()+"] "+err.Error())
+			color = "red"
 		}
-	}()
-
-	if i.server == "" {
-		i.server = "docker.io"
 	}
 
-	for _, v := range s {
+	setText(strings.Join(out, "\n"), color)
+}
 
-		splitString := strings.Split(v, "/")
-		lenString := len(splitString)
-		image := splitString[lenString-1]
+// Tags every source image for the target. An image failing to tag is
+// reported and skipped, source and tag hold the images that were tagged.
+func (t *Target) tagImages(s []string) []error {
 
-		err := cli.ImageTag(ctx, v, i.server+"/"+i.registry+"/"+image)
-		target = append(target, i.server+"/"+i.registry+"/"+image)
-		i.tag = target
-		if err != nil {
-			ErrorLogger.Println(err)
-			panic(err)
-		}
+	if t.server == "" {
+		t.server = "docker.io"
 	}
+	t.source, t.tag = nil, nil
 
-	sString := strings.Join(target, "\n")
-	setText(sString, "white")
+	var errs []error
+	for _, v := range s {
+		target := t.rewriteImage(v)
+		if err := cli.ImageTag(ctx, v, target); err != nil {
+			errs = append(errs, fmt.Errorf("tagging %s: %w", v, err))
+			continue
+		}
+		t.source = append(t.source, v)
+		t.tag = append(t.tag, target)
+	}
+	return errs
 }
 
-// Pushes the tagged images into the registry defined by the user
+// Pushes the tagged images into every target registry
+// Once every push has finished the registries are checked with verifyPush
 func (i *Images) pushImages() {
 
-	if i.tag == nil {
-		log.Printf("There are no tagged images: %v", i.tag)
-		setText("There are no tagged images. Please Tag Images and try again.", "red")
+	var wg sync.WaitGroup
+	pushed := false
+
+	for _, t := range i.activeTargets() {
+		if t.tag == nil {
+			log.Printf("There are no tagged images for %v", t.name())
+			continue
+		}
+		pushMu.Lock()
+		t.failed = map[string]string{}
+		pushMu.Unlock()
+		for _, v := range t.tag {
+			wg.Add(1)
+			t.streamPushToWriter(v, &wg)
+		}
+		pushed = true
 	}
 
-	for _, v := range i.tag {
-		i.streamPushToWriter(v)
+	if !pushed {
+		setText("There are no tagged images. Please Tag Images and try again.", "red")
+		return
 	}
+
+	go func() {
+		wg.Wait()
+		i.verifyPush()
+	}()
 }
 
-// takes the image as a string and streams to io.Writer
+// takes the image as a string and streams the push progress to text
 // Requires username and password to auth
-func (i *Images) streamPushToWriter(image string) {
+func (t *Target) streamPushToWriter(image string, wg *sync.WaitGroup) {
 
 	authConfig := AuthConfig{
-		Username:      i.username,
-		Password:      i.password,
-		ServerAddress: i.server,
+		Username:      t.username,
+		Password:      t.password,
+		ServerAddress: t.server,
 	}
 
 	encodedJSON, err := json.Marshal(authConfig)
@@ -126,26 +218,58 @@ func (i *Images) streamPushToWriter(image string) {
 
 	go func() {
 
+		defer wg.Done()
 		defer func() {
 			if err := recover(); err != nil {
 				log.Println(err)
-				handlePanic(err)
+				t.setFailed(image, fmt.Sprint(err))
+				fmt.Fprintf(text, "[%s] %s: %v\n", t.name(), image, err)
 			}
 		}()
 
 		r, err := cli.ImagePush(ctx, image, types.ImagePushOptions{RegistryAuth: authStr})
 		if err != nil {
-			updateText(nil, err)
 			panic(err)
 		}
 		defer r.Close()
-		io.Copy(text, r)
-		log.Println(text)
-		setText("Success! All Images uploaded successfully.", "green")
 
+		dec := json.NewDecoder(r)
+		for {
+			var msg jsonmessage.JSONMessage
+			if err := dec.Decode(&msg); err == io.EOF {
+				break
+			} else if err != nil {
+				panic(err)
+			}
+			if msg.Error != nil {
+				panic(msg.Error.Message)
+			}
+			// Skip the progress bar updates, only status changes are shown
+			if msg.ProgressMessage != "" || msg.Status == "" {
+				continue
+			}
+			fmt.Fprintf(text, "[%s] %s: %s %s\n", t.name(), image, msg.ID, msg.Status)
+		}
+		fmt.Fprintf(text, "[%s] Pushed %s\n", t.name(), image)
 	}()
 }
 
+var pushMu sync.Mutex
+
+func (t *Target) setFailed(image string, reason string) {
+	pushMu.Lock()
+	defer pushMu.Unlock()
+	t.failed[image] = reason
+}
+
+// Returns the reason the push of an image failed, if it did.
+func (t *Target) failure(image string) (string, bool) {
+	pushMu.Lock()
+	defer pushMu.Unlock()
+	reason, ok := t.failed[image]
+	return reason, ok
+}
+
 // s []string is a slice of images
 func (i *Images) pullImages(s []string) {
 
@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestRewriteImage(t *testing.T) {
+
+	const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	target := Target{server: "registry.local", registry: "cnvrg"}
+
+	tests := []struct {
+		name   string
+		target Target
+		image  string
+		want   string
+	}{
+		{
+			name:   "docker hub short name",
+			target: target,
+			image:  "nginx:1.25",
+			want:   "registry.local/cnvrg/nginx:1.25",
+		},
+		{
+			name:   "docker hub repository",
+			target: target,
+			image:  "cnvrg/app:v5",
+			want:   "registry.local/cnvrg/app:v5",
+		},
+		{
+			name:   "registry with a port",
+			target: Target{server: "harbor.corp:8443", registry: "mirror"},
+			image:  "registry.local:5000/cnvrg/app:v5",
+			want:   "harbor.corp:8443/mirror/app:v5",
+		},
+		{
+			name:   "digest reference",
+			target: target,
+			image:  "docker.io/cnvrg/app@" + digest,
+			want:   "registry.local/cnvrg/app@" + digest,
+		},
+		{
+			name:   "name and tag of a digest reference",
+			target: Target{server: "registry.local", registry: "cnvrg", rewrite: "{server}/{name}:{tag}"},
+			image:  "docker.io/cnvrg/app:v5@" + digest,
+			want:   "registry.local/app:v5",
+		},
+		{
+			name:   "missing tag",
+			target: Target{server: "registry.local", registry: "cnvrg", rewrite: "{server}/{registry}/{name}:{tag}"},
+			image:  "nginx",
+			want:   "registry.local/cnvrg/nginx:latest",
+		},
+		{
+			name:   "empty target",
+			target: Target{},
+			image:  "nginx:1.25",
+			want:   "//nginx:1.25",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.rewriteImage(tt.image); got != tt.want {
+				t.Errorf("rewriteImage(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
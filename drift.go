@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/distribution/reference"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const DRIFT_REPORT_PATH = "drift-report"
+
+const (
+	DRIFT_MISSING_IN_REGISTRY = "missing-in-registry"
+	DRIFT_UNLISTED            = "running-but-unlisted"
+	DRIFT_VERSION_MISMATCH    = "version-mismatch"
+	DRIFT_DIGEST_MISMATCH     = "digest-mismatch"
+)
+
+var driftCategories = []string{DRIFT_MISSING_IN_REGISTRY, DRIFT_UNLISTED, DRIFT_VERSION_MISMATCH, DRIFT_DIGEST_MISMATCH}
+
+type driftEntry struct {
+	Category string   `json:"category"`
+	Image    string   `json:"image"`
+	Expected string   `json:"expected,omitempty"`
+	Actual   string   `json:"actual,omitempty"`
+	Pods     []string `json:"pods,omitempty"`
+}
+
+// An image running in the cluster with the digests the nodes resolved it to.
+type runningImage struct {
+	digests map[string]bool
+	pods    []string
+}
+
+// Returns the images of the running containers keyed by their normalized
+// reference.
+func (v *Versions) runningImages(namespaces []string) (map[string]*runningImage, error) {
+
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	running := map[string]*runningImage{}
+	for _, ns := range namespaces {
+		pods, err := v.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pods.Items {
+			statuses := append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+			for _, c := range statuses {
+				named, err := reference.ParseNormalizedNamed(c.Image)
+				if err != nil {
+					continue
+				}
+				key := reference.TagNameOnly(named).String()
+				r, ok := running[key]
+				if !ok {
+					r = &runningImage{digests: map[string]bool{}}
+					running[key] = r
+				}
+				if _, digest, found := strings.Cut(c.ImageID, "@"); found {
+					r.digests[digest] = true
+				}
+				r.pods = append(r.pods, p.Namespace+"/"+p.Name)
+			}
+		}
+	}
+	return running, nil
+}
+
+// Returns the tag or digest of a normalized reference.
+func refVersion(named reference.Named) string {
+	if tagged, ok := named.(reference.Tagged); ok {
+		return tagged.Tag()
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		return digested.Digest().String()
+	}
+	return "latest"
+}
+
+// Returns the push targets, or an error when one of them was never set up and
+// its rewrite rule would give references like //image.
+func (i *Images) configuredTargets() ([]*Target, error) {
+
+	targets := i.activeTargets()
+	for _, t := range targets {
+		if t.tag != nil {
+			continue
+		}
+		rule := t.rewrite
+		if rule == "" {
+			rule = DEFAULT_REWRITE
+		}
+		if (strings.Contains(rule, "{server}") && t.server == "") || (strings.Contains(rule, "{registry}") && t.registry == "") {
+			return nil, fmt.Errorf("the push target has no server or registry, please set it on the push menu first")
+		}
+	}
+	return targets, nil
+}
+
+// Compares the images running in the cluster, the images file rewritten for
+// the push targets and the tags in the private registries.
+func (i *Images) driftReport(v *Versions, namespaces []string) ([]driftEntry, error) {
+
+	targets, err := i.configuredTargets()
+	if err != nil {
+		return nil, err
+	}
+	source, err := readFile(i.fileName)
+	if err != nil {
+		return nil, err
+	}
+	running, err := v.runningImages(namespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []driftEntry
+
+	// Listed references by repository, with the digest found in the registry
+	// for the pushed ones
+	versions := map[string][]string{}
+	listed := map[string]bool{}
+	registryDigests := map[string]string{}
+
+	// A node may report the digest of the platform manifest of an index
+	platformDigests := map[string]bool{}
+
+	for _, s := range source {
+		if named, err := reference.ParseNormalizedNamed(s); err == nil {
+			named = reference.TagNameOnly(named)
+			listed[named.String()] = true
+			versions[named.Name()] = append(versions[named.Name()], refVersion(named))
+		}
+	}
+
+	for _, t := range targets {
+		clients := map[string]*registryClient{}
+		for _, s := range source {
+			pinned := ""
+			if _, digest, found := strings.Cut(s, "@"); found {
+				pinned = digest
+			}
+			image := t.rewriteImage(strings.Split(s, "@")[0])
+			named, err := reference.ParseNormalizedNamed(image)
+			if err != nil {
+				entries = append(entries, driftEntry{Category: DRIFT_MISSING_IN_REGISTRY, Image: image, Actual: err.Error()})
+				continue
+			}
+			named = reference.TagNameOnly(named)
+			listed[named.String()] = true
+			versions[named.Name()] = append(versions[named.Name()], refVersion(named))
+
+			domain := reference.Domain(named)
+			if clients[domain] == nil {
+				clients[domain] = newRegistryClient(domain, t.username, t.password)
+			}
+			m, err := clients[domain].getManifest(reference.Path(named), refVersion(named))
+			if err != nil {
+				entries = append(entries, driftEntry{Category: DRIFT_MISSING_IN_REGISTRY, Image: named.String(), Actual: err.Error()})
+				continue
+			}
+			registryDigests[named.String()] = m.digest
+			for _, d := range m.Manifests {
+				platformDigests[named.String()+"@"+d.Digest] = true
+			}
+			if pinned != "" && pinned != m.digest {
+				entries = append(entries, driftEntry{Category: DRIFT_DIGEST_MISMATCH, Image: named.String(), Expected: pinned + " (images file)", Actual: m.digest + " (registry)"})
+			}
+		}
+	}
+
+	var keys []string
+	for key := range running {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		r := running[key]
+		named, _ := reference.ParseNormalizedNamed(key)
+		switch {
+		case listed[key]:
+			expected, pushed := registryDigests[key]
+			if !pushed {
+				continue
+			}
+			for digest := range r.digests {
+				if digest != expected && !platformDigests[key+"@"+digest] {
+					entries = append(entries, driftEntry{Category: DRIFT_DIGEST_MISMATCH, Image: key, Expected: expected + " (registry)", Actual: digest + " (running)", Pods: r.pods})
+				}
+			}
+		case len(versions[named.Name()]) > 0:
+			entries = append(entries, driftEntry{Category: DRIFT_VERSION_MISMATCH, Image: named.Name(), Expected: strings.Join(versions[named.Name()], ", "), Actual: refVersion(named), Pods: r.pods})
+		default:
+			entries = append(entries, driftEntry{Category: DRIFT_UNLISTED, Image: key, Pods: r.pods})
+		}
+	}
+	return entries, nil
+}
+
+// Formats the entries grouped by category, as plain text or Markdown.
+func driftText(entries []driftEntry, markdown bool) string {
+
+	var lines []string
+	if markdown {
+		lines = append(lines, "# Image drift report", "")
+	}
+	for _, category := range driftCategories {
+		var group []string
+		for _, e := range entries {
+			if e.Category != category {
+				continue
+			}
+			line := e.Image
+			if e.Expected != "" || e.Actual != "" {
+				line += fmt.Sprintf("  expected: %s  actual: %s", e.Expected, e.Actual)
+			}
+			if len(e.Pods) > 0 {
+				line += "  pods: " + strings.Join(e.Pods, ", ")
+			}
+			if markdown {
+				line = "- `" + e.Image + "`" + strings.TrimPrefix(line, e.Image)
+			} else {
+				line = "  " + line
+			}
+			group = append(group, line)
+		}
+		if markdown {
+			lines = append(lines, fmt.Sprintf("## %s (%d)", category, len(group)), "")
+			lines = append(lines, group...)
+			lines = append(lines, "")
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s (%d):", strings.ToUpper(category), len(group)))
+		lines = append(lines, group...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func writeDriftReport(entries []driftEntry, format string) (string, error) {
+
+	var data []byte
+	switch format {
+	case "json":
+		var err error
+		if data, err = json.MarshalIndent(entries, "", "  "); err != nil {
+			return "", err
+		}
+	case "md":
+		data = []byte(driftText(entries, true))
+	default:
+		return "", fmt.Errorf("unknown format %s", format)
+	}
+	path := DRIFT_REPORT_PATH + "." + format
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// Form to compare the cluster, the images file and the private registry.
+func driftMenu(i *Images) {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	namespaces := strings.Join(v.namespaces(), ", ")
+	var entries []driftEntry
+
+	export := func(format string) {
+		if entries == nil {
+			setText("Please run the report first.", "red")
+			return
+		}
+		path, err := writeDriftReport(entries, format)
+		if err != nil {
+			handlePanic(err)
+			return
+		}
+		setText("Drift report written to "+path, "green")
+	}
+
+	f := showToolForm("Image Drift Report")
+	f.AddInputField("Namespaces: ", namespaces, 40, nil, func(s string) {
+		namespaces = s
+	}).AddButton("Run", func() {
+		setText("Comparing the cluster, "+i.fileName+" and the registry...", "white")
+		go func() {
+			defer func() {
+				if err := recover(); err != nil {
+					ErrorLogger.Println(err)
+					handlePanic(err)
+				}
+			}()
+			result, err := i.driftReport(v, splitList(namespaces))
+			if err != nil {
+				panic(err)
+			}
+			// Export reads the entries on the UI goroutine
+			app.QueueUpdate(func() {
+				entries = append([]driftEntry{}, result...)
+			})
+			color := "green"
+			if len(result) > 0 {
+				color = "red"
+			}
+			setText(driftText(result, false), color)
+		}()
+	}).AddButton("Export JSON", func() {
+		export("json")
+	}).AddButton("Export Markdown", func() {
+		export("md")
+	})
+
+	setText("Compares the running images with the images file, rewritten for the push targets, and the tags in the private registry.", "white")
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	eventPage    = tview.NewFlex()
+	eventForm    = tview.NewForm()
+	eventTable   = tview.NewTable()
+	eventHeaders = []string{"LAST SEEN", "TYPE", "REASON", "OBJECT", "COUNT", "MESSAGE"}
+	eventTypes   = []string{"All", corev1.EventTypeNormal, corev1.EventTypeWarning}
+)
+
+// Events with the same object, reason and message are shown as one row.
+type eventGroup struct {
+	namespace string
+	kind      string
+	name      string
+	eventType string
+	reason    string
+	message   string
+	count     int32
+	lastSeen  time.Time
+}
+
+func (g *eventGroup) object() string {
+	return g.kind + "/" + g.name
+}
+
+// Filters applied to the events before they are grouped, empty fields match
+// everything.
+type eventFilter struct {
+	eventType string
+	kind      string
+	name      string
+	reason    string
+}
+
+func (f eventFilter) matches(e *corev1.Event) bool {
+	return (f.eventType == "" || f.eventType == "All" || e.Type == f.eventType) &&
+		(f.kind == "" || strings.EqualFold(e.InvolvedObject.Kind, f.kind)) &&
+		strings.Contains(e.InvolvedObject.Name, f.name) &&
+		strings.Contains(strings.ToLower(e.Reason), strings.ToLower(f.reason))
+}
+
+// Returns when the event was last observed, the fields set depend on the
+// component that recorded it.
+func eventLastSeen(e *corev1.Event) time.Time {
+	last := e.CreationTimestamp.Time
+	for _, t := range []time.Time{e.FirstTimestamp.Time, e.LastTimestamp.Time, e.EventTime.Time} {
+		if t.After(last) {
+			last = t
+		}
+	}
+	if e.Series != nil && e.Series.LastObservedTime.After(last) {
+		last = e.Series.LastObservedTime.Time
+	}
+	return last
+}
+
+func eventCount(e *corev1.Event) int32 {
+	if e.Series != nil && e.Series.Count > 0 {
+		return e.Series.Count
+	}
+	if e.Count > 0 {
+		return e.Count
+	}
+	return 1
+}
+
+// Groups repeated events, the most recent first.
+func aggregateEvents(events []*corev1.Event) []*eventGroup {
+
+	groups := map[string]*eventGroup{}
+	var result []*eventGroup
+
+	for _, e := range events {
+		key := strings.Join([]string{e.InvolvedObject.Namespace, e.InvolvedObject.Kind, e.InvolvedObject.Name, e.Type, e.Reason, e.Message}, "\x00")
+		g, ok := groups[key]
+		if !ok {
+			g = &eventGroup{
+				namespace: e.Namespace,
+				kind:      e.InvolvedObject.Kind,
+				name:      e.InvolvedObject.Name,
+				eventType: e.Type,
+				reason:    e.Reason,
+				message:   e.Message,
+			}
+			groups[key] = g
+			result = append(result, g)
+		}
+		g.count += eventCount(e)
+		if seen := eventLastSeen(e); seen.After(g.lastSeen) {
+			g.lastSeen = seen
+		}
+	}
+
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].lastSeen.After(result[b].lastSeen)
+	})
+	return result
+}
+
+// Starts an event informer per namespace, or one for all namespaces when
+// none are given.
+func (v *Versions) eventInformers(namespaces []string) []cache.SharedIndexInformer {
+
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	var result []cache.SharedIndexInformer
+	for _, ns := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(&v.clientset, 0, informers.WithNamespace(ns))
+		result = append(result, factory.Core().V1().Events().Informer())
+	}
+	return result
+}
+
+// Page listing the events of the cnvrg namespaces with live updates.
+func eventsMenu() {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	namespaces := strings.Join(v.namespaces(), ", ")
+	filter := eventFilter{}
+	var eventInformers []cache.SharedIndexInformer
+
+	render := func() {
+		var events []*corev1.Event
+		for _, informer := range eventInformers {
+			for _, obj := range informer.GetStore().List() {
+				if e := obj.(*corev1.Event); filter.matches(e) {
+					events = append(events, e)
+				}
+			}
+		}
+		groups := aggregateEvents(events)
+
+		eventTable.Clear()
+		setTableHeader(eventTable, eventHeaders)
+		for n, g := range groups {
+			color := tcell.ColorWhite
+			if g.eventType == corev1.EventTypeWarning {
+				color = tcell.ColorYellow
+			}
+			row := []string{formatAge(g.lastSeen), g.eventType, g.reason, g.namespace + "/" + g.object(), fmt.Sprint(g.count), g.message}
+			for col, value := range row {
+				eventTable.SetCell(n+1, col, tview.NewTableCell(value).SetTextColor(color))
+			}
+		}
+		eventTable.SetTitle(fmt.Sprintf(" Events (%d) ", len(groups)))
+	}
+
+	watch := func() {
+		stop := startWatch()
+		eventInformers = v.eventInformers(splitList(namespaces))
+		watchInformers(stop, render, eventInformers...)
+		render()
+	}
+
+	eventForm.Clear(true)
+	eventForm.SetHorizontal(true).
+		SetBorder(true).
+		SetTitle(" Events ").
+		SetTitleAlign(tview.AlignCenter).
+		SetTitleColor(tcell.ColorGreen)
+
+	eventForm.AddInputField("Namespaces: ", namespaces, 30, nil, func(s string) {
+		namespaces = s
+	}).AddDropDown("Type: ", eventTypes, 0, func(option string, index int) {
+		filter.eventType = option
+		render()
+	}).AddInputField("Kind: ", "", 15, nil, func(s string) {
+		filter.kind = s
+		render()
+	}).AddInputField("Name: ", "", 20, nil, func(s string) {
+		filter.name = s
+		render()
+	}).AddInputField("Reason: ", "", 20, nil, func(s string) {
+		filter.reason = s
+		render()
+	}).AddButton("Return to Tools", func() {
+		showTools()
+	}).AddButton("Apply", func() {
+		watch()
+		app.SetFocus(eventTable)
+	})
+
+	eventTable.SetBorder(true)
+	eventTable.SetFixed(1, 0).
+		SetSelectable(true, false).
+		SetDoneFunc(func(key tcell.Key) {
+			app.SetFocus(eventForm)
+		})
+
+	eventPage.Clear().
+		SetDirection(tview.FlexRow).
+		AddItem(eventForm, 7, 0, false).
+		AddItem(eventTable, 0, 1, true)
+
+	watch()
+	pages.AddAndSwitchToPage("Events", eventPage, true)
+	app.SetFocus(eventTable)
+	setText("Repeated events are shown once with their total count. Apply restarts the watch for the namespaces, Esc moves to the filters.", "white")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAggregateEvents(t *testing.T) {
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	event := func(ns string, name string, reason string, message string, count int32, minute int) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: ns},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: ns, Name: name},
+			Type:           corev1.EventTypeWarning,
+			Reason:         reason,
+			Message:        message,
+			Count:          count,
+			LastTimestamp:  metav1.NewTime(start.Add(time.Duration(minute) * time.Minute)),
+		}
+	}
+	series := event("cnvrg", "app-1", "BackOff", "Back-off restarting failed container", 0, 0)
+	series.Series = &corev1.EventSeries{Count: 7, LastObservedTime: metav1.NewMicroTime(start.Add(30 * time.Minute))}
+
+	tests := []struct {
+		name   string
+		events []*corev1.Event
+		want   []string
+	}{
+		{
+			name:   "no events",
+			events: nil,
+			want:   nil,
+		},
+		{
+			name: "repeated events are grouped",
+			events: []*corev1.Event{
+				event("cnvrg", "app-1", "Failed", "pull failed", 2, 1),
+				event("cnvrg", "app-1", "Failed", "pull failed", 3, 5),
+			},
+			want: []string{"cnvrg Pod/app-1 Failed x5 12:05"},
+		},
+		{
+			name: "different messages are kept apart",
+			events: []*corev1.Event{
+				event("cnvrg", "app-1", "Failed", "pull failed", 1, 1),
+				event("cnvrg", "app-1", "Failed", "create failed", 1, 2),
+			},
+			want: []string{"cnvrg Pod/app-1 Failed x1 12:02", "cnvrg Pod/app-1 Failed x1 12:01"},
+		},
+		{
+			name: "same name in other namespaces is kept apart",
+			events: []*corev1.Event{
+				event("cnvrg", "app-1", "Failed", "pull failed", 1, 1),
+				event("other", "app-1", "Failed", "pull failed", 1, 3),
+			},
+			want: []string{"other Pod/app-1 Failed x1 12:03", "cnvrg Pod/app-1 Failed x1 12:01"},
+		},
+		{
+			name: "series count and last observed time",
+			events: []*corev1.Event{
+				event("cnvrg", "app-2", "Scheduled", "assigned", 0, 10),
+				series,
+			},
+			want: []string{"cnvrg Pod/app-1 BackOff x7 12:30", "cnvrg Pod/app-2 Scheduled x1 12:10"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, g := range aggregateEvents(tt.events) {
+				got = append(got, fmt.Sprintf("%s %s %s x%d %s", g.namespace, g.object(), g.reason, g.count, g.lastSeen.Format("15:04")))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("aggregateEvents() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
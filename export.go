@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const DEFAULT_EXPORT_FILE = "cluster-images.txt"
+
+func podSpecImages(spec corev1.PodSpec) []string {
+	var images []string
+	for _, c := range spec.InitContainers {
+		images = append(images, c.Image)
+	}
+	for _, c := range spec.Containers {
+		images = append(images, c.Image)
+	}
+	return images
+}
+
+// Collects the container and init container images of the pods and workload
+// controllers in the namespaces, all namespaces when none are given. Returns
+// the images sorted and without duplicates.
+func (v *Versions) clusterImages(namespaces []string) ([]string, error) {
+
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	seen := map[string]bool{}
+	add := func(spec corev1.PodSpec) {
+		for _, image := range podSpecImages(spec) {
+			seen[image] = true
+		}
+	}
+
+	for _, ns := range namespaces {
+		pods, err := v.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pods.Items {
+			add(p.Spec)
+		}
+
+		deployments, err := v.clientset.AppsV1().Deployments(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range deployments.Items {
+			add(d.Spec.Template.Spec)
+		}
+
+		statefulSets, err := v.clientset.AppsV1().StatefulSets(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range statefulSets.Items {
+			add(s.Spec.Template.Spec)
+		}
+
+		daemonSets, err := v.clientset.AppsV1().DaemonSets(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range daemonSets.Items {
+			add(d.Spec.Template.Spec)
+		}
+
+		jobs, err := v.clientset.BatchV1().Jobs(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, j := range jobs.Items {
+			add(j.Spec.Template.Spec)
+		}
+
+		cronJobs, err := v.clientset.BatchV1().CronJobs(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cronJobs.Items {
+			add(c.Spec.JobTemplate.Spec.Template.Spec)
+		}
+	}
+
+	var images []string
+	for image := range seen {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// Writes the images running in the cluster to an images file that can be used
+// to pull and save them.
+func (v *Versions) exportClusterImages(namespaces []string, fileName string) {
+	InfoLogger.Println("In the exportClusterImages function")
+
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	images, err := v.clusterImages(namespaces)
+	if err != nil {
+		panic(err)
+	}
+	if len(images) == 0 {
+		setText("No images were found in the namespaces, "+fileName+" was not written.", "red")
+		return
+	}
+
+	if err := os.WriteFile(fileName, []byte(strings.Join(images, "\n")+"\n"), 0644); err != nil {
+		panic(err)
+	}
+
+	setText(fmt.Sprintf("%s\n\nWrote %d images to %s, enter it as the Images File to pull or save them.",
+		strings.Join(images, "\n"), len(images), fileName), "green")
+}
+
+// Form for the namespaces to export and the images file to write.
+func exportMenu() {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	namespaces := strings.Join(v.namespaces(), ", ")
+	fileName := DEFAULT_EXPORT_FILE
+
+	f := showToolForm("Export Cluster Images")
+	f.AddInputField("Namespaces: ", namespaces, 40, nil, func(s string) {
+		namespaces = s
+	}).AddInputField("Images File: ", fileName, 40, nil, func(s string) {
+		fileName = s
+	}).AddButton("Export", func() {
+		go v.exportClusterImages(splitList(namespaces), fileName)
+	})
+
+	setText("Lists the images of the pods, deployments, statefulsets, daemonsets, jobs and cronjobs in the namespaces. Leave Namespaces empty to export all namespaces.", "white")
+}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
@@ -12,18 +19,104 @@ import (
 var (
 	rules      = clientcmd.NewDefaultClientConfigLoadingRules()
 	kubeconfig = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{})
+
+	// Set by initKube once a clientset could be created
+	cluster *Versions
+
+	// Context selected in the UI, empty for the current context of the kube config
+	kubeContext string
 )
 
 type Versions struct {
-	appName      string
-	appNS        string
-	operatorName string
-	operatorNS   string
-	clientset    kubernetes.Clientset
+	appName          string
+	appNS            string
+	appSelector      string
+	operatorName     string
+	operatorNS       string
+	operatorSelector string
+	clientset        kubernetes.Clientset
+
+	// Deployments found by label when the configured ones do not exist, the
+	// version panel refreshes from the rollout goroutines
+	foundMu       sync.Mutex
+	appFound      deploymentRef
+	operatorFound deploymentRef
+}
+
+// A deployment found by label, empty until one was found.
+type deploymentRef struct {
+	name      string
+	namespace string
+}
+
+// State of a cnvrg deployment as shown in the version panel.
+type componentStatus struct {
+	title     string
+	name      string
+	namespace string
+	found     bool
+	ready     int32
+	replicas  int32
+	image     string
+	err       error
+}
+
+// Version is the tag of the first container image.
+func (c componentStatus) version() string {
+	if !c.found {
+		return ""
+	}
+	return imageTag(c.image)
+}
+
+func (c componentStatus) String() string {
+	if !c.found {
+		return fmt.Sprintf("%s: not found (%s/%s): %v", c.title, c.namespace, c.name, c.err)
+	}
+	return fmt.Sprintf("%s version: %s   %s/%s ready %d/%d   %s",
+		c.title, c.version(), c.namespace, c.name, c.ready, c.replicas, c.image)
+}
+
+// Returns the tag of an image reference, latest when it has none.
+func imageTag(image string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		return image[at+1:]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		return image[colon+1:]
+	}
+	return "latest"
+}
+
+// Replaces the tag or digest of an image reference.
+func withImageTag(image string, tag string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		image = image[:colon]
+	}
+	return image + ":" + tag
+}
+
+// Formats the time since t the way kubectl shows ages.
+func formatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
 }
 
 // Initalizes the kube environment, checks for a kube config, if one doesn't
 // exist an error is printed to the screen.
+// The cnvrg deployments default to the standard names and can be changed with
+// the CNVRG_APP_* and CNVRG_OPERATOR_* environment variables.
 func initKube() {
 
 	defer func() {
@@ -33,106 +126,243 @@ func initKube() {
 		}
 	}()
 
-	config, err := kubeconfig.ClientConfig()
+	clientset, err := newClientset()
 	if err != nil {
 		panic(err)
 	}
-	clientset := kubernetes.NewForConfigOrDie(config)
 
-	v := Versions{
-		appName:      "app",
-		appNS:        "cnvrg",
-		operatorName: "cnvrg-operator",
-		operatorNS:   "cnvrg",
-		clientset:    *clientset,
-	}
+	v := newVersions(clientset)
+	cluster = &v
 	v.getVersions()
 
 }
 
-func (v *Versions) getVersions() {
+func newVersions(clientset *kubernetes.Clientset) Versions {
+	return Versions{
+		appName:          envOr("CNVRG_APP_NAME", "app"),
+		appNS:            envOr("CNVRG_APP_NAMESPACE", "cnvrg"),
+		appSelector:      envOr("CNVRG_APP_SELECTOR", "app=app"),
+		operatorName:     envOr("CNVRG_OPERATOR_NAME", "cnvrg-operator"),
+		operatorNS:       envOr("CNVRG_OPERATOR_NAMESPACE", "cnvrg"),
+		operatorSelector: envOr("CNVRG_OPERATOR_SELECTOR", "control-plane=cnvrg-operator"),
+		clientset:        *clientset,
+	}
+}
 
-	appDeploy, err := v.clientset.AppsV1().Deployments(v.appNS).Get(ctx, v.appName, metav1.GetOptions{})
+func newClientset() (*kubernetes.Clientset, error) {
+	config, err := kubeconfig.ClientConfig()
 	if err != nil {
-		log.Println(err)
+		return nil, err
 	}
-	appImage := appDeploy.Spec.Template.Spec.Containers[0].Image
-	appVersion := strings.Split(appImage, ":")
+	return kubernetes.NewForConfig(config)
+}
 
-	operatorDeploy, err := v.clientset.AppsV1().Deployments(v.operatorNS).Get(ctx, v.operatorName, metav1.GetOptions{})
+// Returns the context in use, the current context of the kube config unless
+// another one was selected.
+func currentContext() string {
+	if kubeContext != "" {
+		return kubeContext
+	}
+	raw, err := kubeconfig.RawConfig()
 	if err != nil {
-		log.Println(err)
+		return ""
 	}
-	operatorImage := operatorDeploy.Spec.Template.Spec.Containers[0].Image
-	operatorVersion := strings.Split(operatorImage, ":")
+	return raw.CurrentContext
+}
 
-	final := []string{"cnvrg-app version: " + appVersion[len(appVersion)-1], "operator version: " + operatorVersion[len(operatorVersion)-1]}
+// Switches to another context of the loaded kube configs, rebuilds the
+// clientset and refreshes the version panel. The previous context is kept
+// when the new one can not be used.
+func useContext(name string) error {
 
-	a := strings.Join(final, "\n")
-	setTopText(a, "white")
+	previous := kubeconfig
+	kubeconfig = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: name})
 
+	clientset, err := newClientset()
+	if err != nil {
+		kubeconfig = previous
+		return err
+	}
+	kubeContext = name
+
+	if cluster == nil {
+		initKube()
+		return nil
+	}
+	cluster.clientset = *clientset
+	cluster.foundMu.Lock()
+	cluster.appFound, cluster.operatorFound = deploymentRef{}, deploymentRef{}
+	cluster.foundMu.Unlock()
+	cluster.getVersions()
+	return nil
 }
 
-/*
-func (v Versions) getNodes() {
-	nodeList, err := v.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		panic(err)
+// Adds a kube config file to the loading rules, its contexts are merged with
+// the ones already loaded.
+func addKubeconfig(path string) error {
+
+	if _, err := os.Stat(path); err != nil {
+		return err
 	}
+	rules.Precedence = append(rules.Precedence, path)
+	kubeconfig = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: kubeContext})
+
+	_, err := kubeconfig.RawConfig()
+	return err
+}
 
-	for _, n := range nodeList.Items {
-		fmt.Println(n.Name)
+// Returns the cluster set up by initKube, prints an error to the UI when there
+// is none.
+func requireCluster() *Versions {
+	if cluster == nil {
+		setText("No Kubernetes cluster is configured. Please check your kube config and try again.", "red")
 	}
+	return cluster
 }
-*/
 
-/*
-func (v *Versions) createPod(clientset *kubernetes.Clientset) {
-	newPod := &corev1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "test-pod",
-		},
-		Spec: corev1.PodSpec{
-			Containers: []corev1.Container{
-				{Name: "busybox", Image: "busybox:latest", Command: []string{"sleep", "1000000"}},
-			},
-		},
-	}
-
-	if v.checkPodExists("test-pod", "default") {
-		fmt.Println("Pod already exists")
-	}
-
-	if !v.checkPodExists("test-pod", "default") {
-		pod, err := clientset.CoreV1().Pods("default").Create(ctx, newPod, metav1.CreateOptions{})
-		if err != nil {
-			panic(err)
-		}
-		fmt.Printf("Pod create, %v", pod.Name)
+// Returns the namespace of the cnvrg app, the namespace of a deployment found
+// by label replaces the configured one.
+func (v *Versions) appNamespace() string {
+	v.foundMu.Lock()
+	defer v.foundMu.Unlock()
+	if v.appFound.namespace != "" {
+		return v.appFound.namespace
 	}
+	return v.appNS
 }
-*/
 
-/*
-func (v *Versions) checkPodExists(name string, namespace string) bool {
+// Returns the cnvrg namespaces without duplicates.
+func (v *Versions) namespaces() []string {
 
-	result := false
+	app, operator := v.appNamespace(), v.operatorNS
+	v.foundMu.Lock()
+	if v.operatorFound.namespace != "" {
+		operator = v.operatorFound.namespace
+	}
+	v.foundMu.Unlock()
 
-	pods, err := v.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if app == operator {
+		return []string{app}
+	}
+	return []string{app, operator}
+}
+
+// Returns the configured components with another clientset, without the
+// deployments found by label in this cluster.
+func (v *Versions) withClientset(clientset *kubernetes.Clientset) Versions {
+	return Versions{
+		appName:          v.appName,
+		appNS:            v.appNS,
+		appSelector:      v.appSelector,
+		operatorName:     v.operatorName,
+		operatorNS:       v.operatorNS,
+		operatorSelector: v.operatorSelector,
+		clientset:        *clientset,
+	}
+}
+
+// Looks up a deployment by name, when it does not exist the first deployment
+// matching the label selector in any namespace is used instead.
+func (v *Versions) findDeployment(ctx context.Context, name string, ns string, selector string) (*appsv1.Deployment, error) {
+
+	deploy, err := v.clientset.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+	if err == nil || selector == "" || !errors.IsNotFound(err) {
+		return deploy, err
+	}
+
+	list, lerr := v.clientset.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if lerr != nil {
+		return nil, lerr
+	}
+	if len(list.Items) == 0 {
+		return nil, fmt.Errorf("%v, and no deployment matches %s", err, selector)
+	}
+	InfoLogger.Printf("Deployment %s/%s not found, using %s/%s matching %s", ns, name, list.Items[0].Namespace, list.Items[0].Name, selector)
+	return &list.Items[0], nil
+}
+
+// Returns the status of a component, the deployment found is remembered in
+// found for the other cluster tools.
+func (v *Versions) componentStatus(ctx context.Context, title string, name string, ns string, selector string, found *deploymentRef) componentStatus {
+
+	c := componentStatus{title: title, name: name, namespace: ns}
+
+	deploy, err := v.findDeployment(ctx, name, ns, selector)
 	if err != nil {
-		log.Fatal(err)
+		log.Println(err)
+		c.err = err
+		return c
 	}
 
-	for _, p := range pods.Items {
-		if p.Name == name {
-			fmt.Printf("The pod already exists named: %v\n", p.Name)
-			result = true
+	v.foundMu.Lock()
+	*found = deploymentRef{}
+	if deploy.Name != name || deploy.Namespace != ns {
+		*found = deploymentRef{name: deploy.Name, namespace: deploy.Namespace}
+	}
+	v.foundMu.Unlock()
+	c.name, c.namespace = deploy.Name, deploy.Namespace
+	c.found = true
+	c.ready = deploy.Status.ReadyReplicas
+	if deploy.Spec.Replicas != nil {
+		c.replicas = *deploy.Spec.Replicas
+	}
+	if containers := deploy.Spec.Template.Spec.Containers; len(containers) > 0 {
+		c.image = containers[0].Image
+	}
+	return c
+}
+
+// Returns the status of the cnvrg app and operator deployments.
+func (v *Versions) componentStatuses(ctx context.Context) []componentStatus {
+	return []componentStatus{
+		v.componentStatus(ctx, "cnvrg-app", v.appName, v.appNS, v.appSelector, &v.appFound),
+		v.componentStatus(ctx, "operator", v.operatorName, v.operatorNS, v.operatorSelector, &v.operatorFound),
+	}
+}
+
+func (v *Versions) getVersions() {
+
+	final := []string{"context: " + currentContext()}
+	color := "white"
+	for _, c := range v.componentStatuses(ctx) {
+		if !c.found {
+			color = "red"
 		}
+		final = append(final, c.String())
 	}
-	return result
+
+	a := strings.Join(final, "\n")
+	setTopText(a, color)
+
 }
 
-*/
+// Form to change the cnvrg deployments shown in the version panel.
+func componentsMenu() {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	f := showToolForm("cnvrg Components")
+	f.AddInputField("App Deployment: ", v.appName, 40, nil, func(s string) {
+		v.appName = s
+	}).AddInputField("App Namespace: ", v.appNS, 40, nil, func(s string) {
+		v.appNS = s
+	}).AddInputField("App Selector: ", v.appSelector, 40, nil, func(s string) {
+		v.appSelector = s
+	}).AddInputField("Operator Deployment: ", v.operatorName, 40, nil, func(s string) {
+		v.operatorName = s
+	}).AddInputField("Operator Namespace: ", v.operatorNS, 40, nil, func(s string) {
+		v.operatorNS = s
+	}).AddInputField("Operator Selector: ", v.operatorSelector, 40, nil, func(s string) {
+		v.operatorSelector = s
+	}).AddButton("Refresh Versions", func() {
+		v.getVersions()
+	})
+
+	setText("When a deployment is not found by name, the first deployment matching the label selector in any namespace is used.", "white")
+}
 
 /*
 
@@ -152,25 +382,6 @@ func (v *Versions) getImage(name string, namespace string) {
 */
 
 /*
-func getResourcesDynamically(dynamic dynamic.Interface, ctx context.Context,
-	group string, version string, resource string, namespace string) (
-	[]unstructured.Unstructured, error) {
-
-	resourceId := schema.GroupVersionResource{
-		Group:    group,
-		Version:  version,
-		Resource: resource,
-	}
-	list, err := dynamic.Resource(resourceId).Namespace(namespace).
-		List(ctx, metav1.ListOptions{})
-
-	if err != nil {
-		return nil, err
-	}
-
-	return list.Items, nil
-}
-
 func GetDeployments(clientset *kubernetes.Clientset, ctx context.Context,
 	namespace string) ([]v1.Deployment, error) {
 
@@ -182,46 +393,6 @@ func GetDeployments(clientset *kubernetes.Clientset, ctx context.Context,
 	return list.Items, nil
 }
 
-/*
-func getPvc(clientset *kubernetes.Clientset, ctx context.Context,
-	namespace string) {
-
-	//var ns, label, field string
-
-	ns := "cnvrg"
-	label := "cnvrg-control-plane"
-	field := ""
-
-	flag.StringVar(&ns, "namespace", "", "namespace")
-	flag.StringVar(&label, "l", "", "Label selector")
-	flag.StringVar(&field, "f", "", "Field selector")
-
-	api := clientset.CoreV1()
-	// setup list options
-	listOptions := metav1.ListOptions{
-		LabelSelector: label,
-		FieldSelector: field,
-	}
-	pvcs, err := api.PersistentVolumeClaims(ns).List(listOptions)
-	if err != nil {
-		log.Fatal(err)
-	}
-	printPVCs(pvcs)
-}
-
-func printPVCs(pvcs *v1.PersistentVolumeClaimList) {
-	template := "%-32s%-8s%-8s\n"
-	fmt.Printf(template, "NAME", "STATUS", "CAPACITY")
-	for _, pvc := range pvcs.Items {
-		quant := pvc.Spec.Resources.Requests[v1.ResourceStorage]
-		fmt.Printf(
-			template,
-			pvc.Name,
-			string(pvc.Status.Phase),
-			quant.String())
-	}
-}
-
 func GetDeployments(clientset *kubernetes.Clientset, ctx context.Context,
 	namespace string) ([]v1.Deployment, error) {
 
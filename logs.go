@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const MAX_LOG_LINES = 10000
+
+var (
+	logPage = tview.NewFlex()
+	logForm = tview.NewForm()
+	logView = tview.NewTextView()
+)
+
+// Log lines of the stream on screen, kept so the view can be redrawn with a
+// new search and saved to a file.
+type logBuffer struct {
+	mu     sync.Mutex
+	lines  []string
+	search string
+	cancel context.CancelFunc
+}
+
+var logs = &logBuffer{}
+
+// Escapes a line for the text view and highlights the search term.
+func (b *logBuffer) format(line string) string {
+	if b.search == "" {
+		return tview.Escape(line) + "\n"
+	}
+	parts := strings.Split(line, b.search)
+	for n := range parts {
+		parts[n] = tview.Escape(parts[n])
+	}
+	return strings.Join(parts, "[black:yellow]"+tview.Escape(b.search)+"[-:-]") + "\n"
+}
+
+func (b *logBuffer) add(line string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lines = append(b.lines, line)
+	if len(b.lines) > MAX_LOG_LINES {
+		b.lines = b.lines[len(b.lines)-MAX_LOG_LINES:]
+	}
+	fmt.Fprint(logView, b.format(line))
+}
+
+// Redraws the view with the search term highlighted.
+func (b *logBuffer) setSearch(search string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.search = search
+	var s strings.Builder
+	matches := 0
+	for _, line := range b.lines {
+		if search != "" && strings.Contains(line, search) {
+			matches++
+		}
+		s.WriteString(b.format(line))
+	}
+	logView.SetText(s.String())
+	if search != "" {
+		setText(fmt.Sprintf("%d lines match %q", matches, search), "white")
+	}
+}
+
+// Stops the stream on screen and clears the buffer.
+func (b *logBuffer) reset() context.Context {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.cancel != nil {
+		b.cancel()
+	}
+	b.lines = nil
+	logView.Clear()
+	c, cancel := context.WithCancel(ctx)
+	b.cancel = cancel
+	return c
+}
+
+func (b *logBuffer) save(fileName string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return os.WriteFile(fileName, []byte(strings.Join(b.lines, "\n")+"\n"), 0644)
+}
+
+// Streams the logs of a container into the log view until the stream ends or
+// is cancelled.
+func (v *Versions) streamLogs(c context.Context, p *corev1.Pod, opts *corev1.PodLogOptions) {
+
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	stream, err := v.clientset.CoreV1().Pods(p.Namespace).GetLogs(p.Name, opts).Stream(c)
+	if err != nil {
+		panic(err)
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if c.Err() != nil {
+			return
+		}
+		logs.add(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil && c.Err() == nil {
+		panic(err)
+	}
+	if c.Err() == nil {
+		setText("End of logs for "+p.Name+"/"+opts.Container, "white")
+	}
+}
+
+// Page showing the logs of a container of the pod, opened from the pods page.
+func logsMenu(p *corev1.Pod) {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	var containers []string
+	for _, c := range p.Spec.InitContainers {
+		containers = append(containers, c.Name)
+	}
+	for _, c := range p.Spec.Containers {
+		containers = append(containers, c.Name)
+	}
+
+	container := p.Spec.Containers[0].Name
+	tail, since := "500", ""
+	previous, follow := false, true
+
+	load := func() {
+		opts := &corev1.PodLogOptions{Container: container, Previous: previous, Follow: follow}
+		if tail != "" {
+			lines, err := strconv.ParseInt(tail, 10, 64)
+			if err != nil {
+				setText("Tail Lines must be a number.", "red")
+				return
+			}
+			opts.TailLines = &lines
+		}
+		if since != "" {
+			d, err := time.ParseDuration(since)
+			if err != nil {
+				setText("Since must be a duration such as 10m or 2h.", "red")
+				return
+			}
+			seconds := int64(d.Seconds())
+			opts.SinceSeconds = &seconds
+		}
+
+		c := logs.reset()
+		setText(fmt.Sprintf("Logs of %s/%s container %s", p.Namespace, p.Name, container), "white")
+		go v.streamLogs(c, p, opts)
+	}
+
+	logForm.Clear(true)
+	logForm.SetHorizontal(true).
+		SetBorder(true).
+		SetTitle(" Logs: " + p.Namespace + "/" + p.Name + " ").
+		SetTitleAlign(tview.AlignCenter).
+		SetTitleColor(tcell.ColorGreen)
+
+	logForm.AddDropDown("Container: ", containers, len(p.Spec.InitContainers), func(option string, index int) {
+		container = option
+	}).AddInputField("Tail Lines: ", tail, 8, nil, func(s string) {
+		tail = s
+	}).AddInputField("Since: ", since, 8, nil, func(s string) {
+		since = s
+	}).AddCheckbox("Previous: ", previous, func(checked bool) {
+		previous = checked
+	}).AddCheckbox("Follow: ", follow, func(checked bool) {
+		follow = checked
+	}).AddInputField("Search: ", "", 20, nil, func(s string) {
+		logs.setSearch(s)
+	}).AddButton("Return to Pods", func() {
+		logs.reset()
+		pages.SwitchToPage("Pods")
+		app.SetFocus(podTable)
+	}).AddButton("Load", func() {
+		load()
+		app.SetFocus(logView)
+	}).AddButton("Save", func() {
+		fileName := p.Name + "-" + container + ".log"
+		if err := logs.save(fileName); err != nil {
+			handlePanic(err)
+			return
+		}
+		setText("Logs saved to "+fileName, "green")
+	})
+
+	logView.SetDynamicColors(true).
+		SetScrollable(true).
+		SetMaxLines(MAX_LOG_LINES).
+		SetChangedFunc(func() {
+			app.Draw()
+		}).
+		SetDoneFunc(func(key tcell.Key) {
+			app.SetFocus(logForm)
+		}).
+		SetBorder(true)
+
+	logPage.Clear().
+		SetDirection(tview.FlexRow).
+		AddItem(logForm, 7, 0, false).
+		AddItem(logView, 0, 1, true)
+
+	logs.search = ""
+	load()
+	pages.AddAndSwitchToPage("Logs", logPage, true)
+	app.SetFocus(logView)
+}
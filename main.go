@@ -5,6 +5,8 @@ import (
 	"os"
 )
 
+const LOG_FILE_PATH = "logs.txt"
+
 var (
 	//ctx    = context.Background()
 	//cli, _ = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
@@ -16,8 +18,6 @@ var (
 
 func init() {
 
-	LOG_FILE_PATH := "logs.txt"
-
 	file, error := os.OpenFile(LOG_FILE_PATH, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
 	if error != nil {
 		log.Fatal(error)
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/distribution/reference"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const DEFAULT_MANIFEST_FILE = "manifest-images.txt"
+
+// Where an image reference was found in the manifests.
+type imageSource struct {
+	file   string
+	object string
+	path   string
+}
+
+func (s imageSource) String() string {
+	return s.file + ": " + s.object + " " + s.path
+}
+
+// Walks a file or directory and collects the image references of every YAML
+// or JSON document found, keyed by image.
+func extractImages(root string) (map[string][]imageSource, error) {
+
+	images := map[string][]imageSource{}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			if path != root {
+				return nil
+			}
+		}
+		return extractFileImages(path, images)
+	})
+	return images, err
+}
+
+// Decodes every document of a multi document file.
+func extractFileImages(path string, images map[string][]imageSource) error {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	dec := yaml.NewYAMLOrJSONDecoder(file, 4096)
+	for n := 0; ; n++ {
+		var doc map[string]interface{}
+		if err := dec.Decode(&doc); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s: document %d: %v", path, n+1, err)
+		}
+		if doc == nil {
+			continue
+		}
+
+		object := documentName(doc, n)
+		findImages(doc, "", func(image string, at string) {
+			images[image] = append(images[image], imageSource{file: path, object: object, path: at})
+		})
+	}
+}
+
+// Kubernetes objects are named kind/namespace/name, other documents such as
+// Helm values by their position in the file.
+func documentName(doc map[string]interface{}, n int) string {
+
+	kind, _ := doc["kind"].(string)
+	if kind == "" {
+		return fmt.Sprintf("document %d", n+1)
+	}
+	metadata, _ := doc["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	if ns, _ := metadata["namespace"].(string); ns != "" {
+		name = ns + "/" + name
+	}
+	return kind + "/" + name
+}
+
+// Recursively looks for image references. Strings under an image key are
+// taken as is, maps with a repository key follow the Helm convention of
+// registry, repository and tag or digest keys. This covers pod specs as well
+// as custom resources and values files.
+func findImages(node interface{}, at string, found func(string, string)) {
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if image, ok := n["image"].(string); ok && isImageRef(image) {
+			found(image, strings.TrimPrefix(at+".image", "."))
+		}
+		if repo, ok := n["repository"].(string); ok && isImageRef(repo) {
+			image := repo
+			if registry, _ := n["registry"].(string); registry != "" {
+				image = registry + "/" + image
+			}
+			if digest, _ := n["digest"].(string); digest != "" {
+				image += "@" + digest
+			} else if tag := scalarString(n["tag"]); tag != "" {
+				image += ":" + tag
+			}
+			// Chart dependencies have a repository URL, not an image
+			if isImageRef(image) {
+				found(image, strings.TrimPrefix(at+".repository", "."))
+			}
+		}
+		for k, v := range n {
+			findImages(v, at+"."+k, found)
+		}
+	case []interface{}:
+		for idx, v := range n {
+			findImages(v, fmt.Sprintf("%s[%d]", at, idx), found)
+		}
+	}
+}
+
+// Tags are often written unquoted and decoded as numbers.
+func scalarString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case int64:
+		return strconv.FormatInt(t, 10)
+	}
+	return ""
+}
+
+// Skips empty values, unrendered templates, URLs and anything else that does
+// not parse as an image reference.
+func isImageRef(s string) bool {
+	if s == "" || strings.ContainsAny(s, " {}$") || strings.Contains(s, "://") {
+		return false
+	}
+	_, err := reference.ParseNormalizedNamed(s)
+	return err == nil
+}
+
+// Writes the images found under root to an images manifest. Every image is
+// preceded by comments naming the file and object it came from, readFile
+// skips the comments so the manifest can be used as the images file.
+func writeManifestImages(root string, fileName string) {
+	InfoLogger.Println("In the writeManifestImages function")
+
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	found, err := extractImages(root)
+	if err != nil {
+		panic(err)
+	}
+
+	var images []string
+	for image := range found {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Images extracted from %s by cnvrg-dep-tool\n", root)
+	for _, image := range images {
+		b.WriteString("\n")
+		for _, s := range found[image] {
+			fmt.Fprintf(&b, "# %s\n", s)
+		}
+		b.WriteString(image + "\n")
+	}
+
+	if err := os.WriteFile(fileName, []byte(b.String()), 0644); err != nil {
+		panic(err)
+	}
+
+	setText(fmt.Sprintf("%s\n\nWrote %d images to %s", strings.Join(images, "\n"), len(images), fileName), "green")
+}
+
+// Form for the manifests to read and the manifest to write.
+func extractMenu() {
+
+	root := ""
+	fileName := DEFAULT_MANIFEST_FILE
+
+	f := showToolForm("Extract Images From Manifests")
+	f.AddInputField("Manifests Path: ", root, 40, nil, func(s string) {
+		root = s
+	}).AddInputField("Images File: ", fileName, 40, nil, func(s string) {
+		fileName = s
+	}).AddButton("Extract", func() {
+		writeManifestImages(root, fileName)
+	})
+
+	setText("Enter a directory or a YAML file, for example the operator manifests or helm template output.", "white")
+}
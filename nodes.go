@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+)
+
+var (
+	nodeTable   = tview.NewTable()
+	nodeHeaders = []string{"NAME", "ROLES", "READY", "VERSION", "OS/ARCH", "CPU", "MEMORY", "EPHEMERAL", "EXTENDED", "TAINTS", "AGE"}
+)
+
+// A row of the nodes table. Quantities and age are also kept as numbers so
+// those columns sort by value.
+type nodeRow struct {
+	cells   []string
+	numbers map[int]float64
+	ready   bool
+}
+
+func newNodeRow(n *corev1.Node) nodeRow {
+
+	var roles []string
+	for label := range n.Labels {
+		if role, ok := strings.CutPrefix(label, "node-role.kubernetes.io/"); ok {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	if len(roles) == 0 {
+		roles = []string{"<none>"}
+	}
+
+	ready := "Unknown"
+	for _, c := range n.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			ready = string(c.Status)
+		}
+	}
+
+	// Extended resources are the allocatable resources with a domain prefix,
+	// for example nvidia.com/gpu
+	var extended []string
+	for name, q := range n.Status.Allocatable {
+		if strings.Contains(string(name), "/") && !strings.Contains(string(name), "kubernetes.io/") {
+			extended = append(extended, fmt.Sprintf("%s=%s", name, q.String()))
+		}
+	}
+	sort.Strings(extended)
+
+	var taints []string
+	for _, t := range n.Spec.Taints {
+		taints = append(taints, t.ToString())
+	}
+
+	alloc := n.Status.Allocatable
+	cpu, memory, ephemeral := alloc[corev1.ResourceCPU], alloc[corev1.ResourceMemory], alloc[corev1.ResourceEphemeralStorage]
+
+	return nodeRow{
+		cells: []string{
+			n.Name,
+			strings.Join(roles, ","),
+			ready,
+			n.Status.NodeInfo.KubeletVersion,
+			n.Status.NodeInfo.OperatingSystem + "/" + n.Status.NodeInfo.Architecture,
+			cpu.String(),
+			memory.String(),
+			ephemeral.String(),
+			strings.Join(extended, " "),
+			strings.Join(taints, " "),
+			formatAge(n.CreationTimestamp.Time),
+		},
+		numbers: map[int]float64{
+			5:  cpu.AsApproximateFloat64(),
+			6:  memory.AsApproximateFloat64(),
+			7:  ephemeral.AsApproximateFloat64(),
+			10: -float64(n.CreationTimestamp.Unix()),
+		},
+		ready: ready == string(corev1.ConditionTrue),
+	}
+}
+
+// Sorts rows by a column, numerically when the column has numbers.
+func sortNodeRows(rows []nodeRow, col int, desc bool) {
+	sort.SliceStable(rows, func(a, b int) bool {
+		if desc {
+			a, b = b, a
+		}
+		if x, ok := rows[a].numbers[col]; ok {
+			return x < rows[b].numbers[col]
+		}
+		return rows[a].cells[col] < rows[b].cells[col]
+	})
+}
+
+// Page listing the cluster nodes, kept up to date by a node informer. Press s
+// to sort by the next column and r to reverse the order.
+func nodesMenu() {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	sortCol, desc := 0, false
+	factory := informers.NewSharedInformerFactory(&v.clientset, 0)
+	informer := factory.Core().V1().Nodes().Informer()
+
+	render := func() {
+		var rows []nodeRow
+		for _, obj := range informer.GetStore().List() {
+			rows = append(rows, newNodeRow(obj.(*corev1.Node)))
+		}
+		sortNodeRows(rows, sortCol, desc)
+
+		nodeTable.Clear()
+		setTableHeader(nodeTable, nodeHeaders)
+		for n, r := range rows {
+			color := tcell.ColorWhite
+			if !r.ready {
+				color = tcell.ColorRed
+			}
+			for col, value := range r.cells {
+				nodeTable.SetCell(n+1, col, tview.NewTableCell(value).SetTextColor(color))
+			}
+		}
+		nodeTable.SetTitle(fmt.Sprintf(" Nodes (%d) sorted by %s ", len(rows), strings.ToLower(nodeHeaders[sortCol])))
+	}
+
+	nodeTable.Clear()
+	nodeTable.SetBorder(true)
+	nodeTable.SetFixed(1, 1).
+		SetSelectable(true, false).
+		SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEscape {
+				showTools()
+			}
+		})
+	nodeTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 's':
+			sortCol = (sortCol + 1) % len(nodeHeaders)
+		case 'r':
+			desc = !desc
+		default:
+			return event
+		}
+		render()
+		return nil
+	})
+
+	stop := startWatch()
+	watchInformers(stop, render, informer)
+
+	render()
+	pages.AddAndSwitchToPage("Nodes", nodeTable, true)
+	app.SetFocus(nodeTable)
+	setText("Press s to sort by the next column, r to reverse the order and Esc to return.", "white")
+}
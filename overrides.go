@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/distribution/reference"
+	"sigs.k8s.io/yaml"
+)
+
+var overrideFormats = []string{"helm", "kustomize", "csv"}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+type helmImage struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag,omitempty"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+// Registry values of the cnvrg chart, the same fields the custom resource
+// takes in spec.registry.
+type helmRegistry struct {
+	URL      string `json:"url"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+type kustomizeImage struct {
+	Name    string `json:"name"`
+	NewName string `json:"newName"`
+	NewTag  string `json:"newTag,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+}
+
+// Returns the source and target images of t. When the images have not been
+// tagged yet the mapping is computed from the images file with the same
+// rewrite rule tagImages uses.
+func (i *Images) imageMapping(t *Target) ([]string, []string, error) {
+
+	if t.tag != nil {
+		return t.source, t.tag, nil
+	}
+
+	source, err := readFile(i.fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	var target []string
+	for _, v := range source {
+		target = append(target, t.rewriteImage(v))
+	}
+	return source, target, nil
+}
+
+// Writes an override file in the given format for every push target. The
+// files are written next to the images file.
+func (i *Images) writeOverrides(format string) {
+	InfoLogger.Println("In the writeOverrides function")
+
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	targets := i.activeTargets()
+	var written []string
+
+	for _, t := range targets {
+		source, target, err := i.imageMapping(t)
+		if err != nil {
+			panic(err)
+		}
+
+		var data []byte
+		ext := ".yaml"
+		perm := os.FileMode(0644)
+		switch format {
+		case "helm":
+			data, err = helmOverrides(t, target)
+			// The values hold the registry password
+			perm = 0600
+		case "kustomize":
+			data, err = kustomizeOverrides(source, target)
+		case "csv":
+			data, err = csvOverrides(source, target)
+			ext = ".csv"
+		default:
+			err = fmt.Errorf("unknown override format %q", format)
+		}
+		if err != nil {
+			panic(err)
+		}
+
+		name := "image-overrides-" + format
+		if len(targets) > 1 {
+			name += "-" + unsafeFileChars.ReplaceAllString(t.name(), "_")
+		}
+		path := filepath.Join(filepath.Dir(i.fileName), name+ext)
+		if err := os.WriteFile(path, data, perm); err != nil {
+			panic(err)
+		}
+		written = append(written, path)
+	}
+
+	setText("Override files written:\n"+strings.Join(written, "\n"), "green")
+}
+
+// Splits an image reference into repository and tag, a digest reference is
+// returned with an empty tag.
+func splitImage(image string) (string, string, string) {
+
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		name, tag, _ := strings.Cut(image, ":")
+		return name, tag, ""
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		return named.Name(), "", digested.Digest().String()
+	}
+	tag := "latest"
+	if tagged, ok := named.(reference.Tagged); ok {
+		tag = tagged.Tag()
+	}
+	return named.Name(), tag, ""
+}
+
+// Helm values for the cnvrg chart. imageHub and registry point the chart at
+// the push target, images lists the repository and tag or digest of every
+// image keyed by image name, for charts that pin images one by one.
+func helmOverrides(t *Target, target []string) ([]byte, error) {
+
+	images := map[string]helmImage{}
+	for _, image := range target {
+		repo, tag, digest := splitImage(image)
+
+		base := repo[strings.LastIndex(repo, "/")+1:]
+		key := base
+		for n := 2; ; n++ {
+			if _, ok := images[key]; !ok {
+				break
+			}
+			key = fmt.Sprintf("%s-%d", base, n)
+		}
+		images[key] = helmImage{Repository: repo, Tag: tag, Digest: digest}
+	}
+
+	values, err := yaml.Marshal(map[string]interface{}{
+		"imageHub": t.name(),
+		"registry": helmRegistry{URL: t.server, User: t.username, Password: t.password},
+		"images":   images,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("# Image overrides generated by cnvrg-dep-tool\n"), values...), nil
+}
+
+// A kustomize images block, name is the source image as written in the
+// images file without its tag.
+func kustomizeOverrides(source []string, target []string) ([]byte, error) {
+
+	var images []kustomizeImage
+	for n, t := range target {
+		name := source[n]
+		if at := strings.Index(name, "@"); at >= 0 {
+			name = name[:at]
+		} else if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+			name = name[:colon]
+		}
+
+		repo, tag, digest := splitImage(t)
+		images = append(images, kustomizeImage{Name: name, NewName: repo, NewTag: tag, Digest: digest})
+	}
+
+	return yaml.Marshal(map[string]interface{}{"images": images})
+}
+
+func csvOverrides(source []string, target []string) ([]byte, error) {
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write([]string{"source", "target"})
+	for n, t := range target {
+		w.Write([]string{source[n], t})
+	}
+	w.Flush()
+	return []byte(b.String()), w.Error()
+}
+
+// Form to select the override format
+func overridesMenu(i *Images) {
+
+	format := overrideFormats[0]
+
+	f := showToolForm("Image Overrides")
+	f.AddDropDown("Format: ", overrideFormats, 0, func(option string, index int) {
+		format = option
+	}).AddButton("Generate", func() {
+		i.writeOverrides(format)
+	})
+
+	setText("Generates a Helm values file, a kustomize images block or a CSV mapping from the source images to the push targets.", "white")
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const OVERVIEW_EXPORT_PATH = "cluster-overview"
+
+var (
+	overviewPage  = tview.NewFlex()
+	overviewForm  = tview.NewForm()
+	overviewTable = tview.NewTable()
+)
+
+// One row of the multi cluster overview.
+type clusterOverview struct {
+	Context         string `json:"context"`
+	APIVersion      string `json:"apiVersion"`
+	AppVersion      string `json:"appVersion"`
+	OperatorVersion string `json:"operatorVersion"`
+	Reachable       bool   `json:"reachable"`
+	Error           string `json:"error,omitempty"`
+}
+
+// Collects the versions of every context concurrently. Each cluster gets its
+// own client with the timeout so an unreachable cluster does not hold up the
+// others.
+func overviewClusters(contexts []string, timeout time.Duration) []clusterOverview {
+
+	raw, err := kubeconfig.RawConfig()
+	if err != nil {
+		return []clusterOverview{{Error: err.Error()}}
+	}
+	if len(contexts) == 0 {
+		for name := range raw.Contexts {
+			contexts = append(contexts, name)
+		}
+	}
+	sort.Strings(contexts)
+
+	results := make([]clusterOverview, len(contexts))
+	var wg sync.WaitGroup
+	for n, name := range contexts {
+		wg.Add(1)
+		go func(n int, name string) {
+			defer wg.Done()
+			results[n] = overviewCluster(raw, name, timeout)
+		}(n, name)
+	}
+	wg.Wait()
+	return results
+}
+
+func overviewCluster(raw clientcmdapi.Config, name string, timeout time.Duration) clusterOverview {
+
+	o := clusterOverview{Context: name}
+
+	config, err := clientcmd.NewNonInteractiveClientConfig(raw, name, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		o.Error = err.Error()
+		return o
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		o.Error = err.Error()
+		return o
+	}
+
+	// The timeout covers every request to the cluster, not each one
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := clientset.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Raw()
+	if err != nil {
+		o.Error = err.Error()
+		return o
+	}
+	var info version.Info
+	if err := json.Unmarshal(body, &info); err != nil {
+		o.Error = err.Error()
+		return o
+	}
+	o.Reachable = true
+	o.APIVersion = info.GitVersion
+
+	// Use the component names configured for the current cluster
+	v := newVersions(clientset)
+	if cluster != nil {
+		v = cluster.withClientset(clientset)
+	}
+	statuses := v.componentStatuses(ctx)
+	o.AppVersion = statuses[0].version()
+	o.OperatorVersion = statuses[1].version()
+	for _, s := range statuses {
+		if !s.found {
+			o.Error = s.err.Error()
+		}
+	}
+	return o
+}
+
+func showOverview(results []clusterOverview) {
+
+	overviewTable.Clear()
+	setTableHeader(overviewTable, []string{"CONTEXT", "API VERSION", "CNVRG APP", "OPERATOR", "REACHABLE", "ERROR"})
+
+	for n, o := range results {
+		color := tcell.ColorWhite
+		if !o.Reachable {
+			color = tcell.ColorRed
+		}
+		row := []string{o.Context, o.APIVersion, o.AppVersion, o.OperatorVersion, strconv.FormatBool(o.Reachable), o.Error}
+		for col, value := range row {
+			overviewTable.SetCell(n+1, col, tview.NewTableCell(value).SetTextColor(color))
+		}
+	}
+}
+
+// Writes the overview to cluster-overview.csv or cluster-overview.json.
+func exportOverview(results []clusterOverview, format string) {
+
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	path := OVERVIEW_EXPORT_PATH + "." + format
+	var data []byte
+
+	if format == "json" {
+		out, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			panic(err)
+		}
+		data = out
+	} else {
+		var b strings.Builder
+		w := csv.NewWriter(&b)
+		w.Write([]string{"context", "apiVersion", "appVersion", "operatorVersion", "reachable", "error"})
+		for _, o := range results {
+			w.Write([]string{o.Context, o.APIVersion, o.AppVersion, o.OperatorVersion, strconv.FormatBool(o.Reachable), o.Error})
+		}
+		w.Flush()
+		data = []byte(b.String())
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		panic(err)
+	}
+	setText("Overview written to "+path, "green")
+}
+
+// Page with the cnvrg versions of all or the selected kube contexts.
+func overviewMenu() {
+
+	contexts := ""
+	timeout := "10"
+	var results []clusterOverview
+
+	refresh := func() {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil || seconds <= 0 {
+			setText("Please enter the timeout in seconds.", "red")
+			return
+		}
+		setText("Collecting versions...", "white")
+		go func() {
+			r := overviewClusters(splitList(contexts), time.Duration(seconds)*time.Second)
+			app.QueueUpdateDraw(func() {
+				results = r
+				showOverview(results)
+			})
+			setText(fmt.Sprintf("Collected versions of %d clusters", len(r)), "green")
+		}()
+	}
+
+	overviewForm.Clear(true)
+	overviewForm.SetBorder(true).
+		SetTitle(" Multi-cluster Overview ").
+		SetTitleAlign(tview.AlignCenter).
+		SetTitleColor(tcell.ColorGreen)
+
+	overviewForm.AddInputField("Contexts: ", contexts, 40, nil, func(s string) {
+		contexts = s
+	}).AddInputField("Timeout (seconds): ", timeout, 10, nil, func(s string) {
+		timeout = s
+	}).AddButton("Return to Tools", func() {
+		showTools()
+	}).AddButton("Refresh", func() {
+		refresh()
+	}).AddButton("Export CSV", func() {
+		exportOverview(results, "csv")
+	}).AddButton("Export JSON", func() {
+		exportOverview(results, "json")
+	})
+
+	overviewTable.SetBorder(true)
+	overviewTable.SetFixed(1, 0).
+		SetSelectable(true, false).
+		SetDoneFunc(func(key tcell.Key) {
+			app.SetFocus(overviewForm)
+		})
+
+	overviewPage.Clear().
+		SetDirection(tview.FlexRow).
+		AddItem(overviewForm, 9, 0, true).
+		AddItem(overviewTable, 0, 1, false)
+
+	showOverview(nil)
+	pages.AddAndSwitchToPage("Overview", overviewPage, true)
+	app.SetFocus(overviewForm)
+	setText("Leave Contexts empty to check every context of the loaded kube configs.", "white")
+}
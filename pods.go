@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	podPage    = tview.NewFlex()
+	podForm    = tview.NewForm()
+	podTable   = tview.NewTable()
+	podHeaders = []string{"NAMESPACE", "NAME", "STATUS", "READY", "RESTARTS", "NODE", "AGE", "IMAGE"}
+)
+
+// Returns the status kubectl would show for the pod, the reason a container
+// is waiting or terminated takes precedence over the pod phase.
+func podStatus(p *corev1.Pod) string {
+
+	status := string(p.Status.Phase)
+	if p.Status.Reason != "" {
+		status = p.Status.Reason
+	}
+	statuses := append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+	for _, c := range statuses {
+		if c.State.Waiting != nil && c.State.Waiting.Reason != "" && c.State.Waiting.Reason != "PodInitializing" {
+			return c.State.Waiting.Reason
+		}
+		if c.State.Terminated != nil && c.State.Terminated.ExitCode != 0 {
+			if c.State.Terminated.Reason == "" {
+				return fmt.Sprintf("ExitCode:%d", c.State.Terminated.ExitCode)
+			}
+			return c.State.Terminated.Reason
+		}
+	}
+	if p.DeletionTimestamp != nil {
+		return "Terminating"
+	}
+	return status
+}
+
+func podStatusColor(status string) tcell.Color {
+	switch status {
+	case "CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull", "Error", "InvalidImageName", "CreateContainerConfigError":
+		return tcell.ColorRed
+	case string(corev1.PodPending), "ContainerCreating", "Terminating":
+		return tcell.ColorYellow
+	case string(corev1.PodSucceeded):
+		return tcell.ColorGray
+	}
+	return tcell.ColorWhite
+}
+
+func podRow(p *corev1.Pod) []string {
+
+	ready, restarts := 0, int32(0)
+	for _, c := range p.Status.ContainerStatuses {
+		if c.Ready {
+			ready++
+		}
+		restarts += c.RestartCount
+	}
+
+	return []string{
+		p.Namespace,
+		p.Name,
+		podStatus(p),
+		fmt.Sprintf("%d/%d", ready, len(p.Spec.Containers)),
+		fmt.Sprint(restarts),
+		p.Spec.NodeName,
+		formatAge(p.CreationTimestamp.Time),
+		strings.Join(podSpecImages(p.Spec), ","),
+	}
+}
+
+// Starts a pod informer per namespace, or one for all namespaces when none
+// are given.
+func (v *Versions) podInformers(namespaces []string) []cache.SharedIndexInformer {
+
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	var result []cache.SharedIndexInformer
+	for _, ns := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(&v.clientset, 0, informers.WithNamespace(ns))
+		result = append(result, factory.Core().V1().Pods().Informer())
+	}
+	return result
+}
+
+// Page listing the pods of the cnvrg namespaces, kept up to date by
+// informers. Pods can be filtered by name and label selector.
+func podsMenu() {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	namespaces := strings.Join(v.namespaces(), ", ")
+	nameFilter, labelFilter := "", ""
+	var podInformers []cache.SharedIndexInformer
+	var shown []*corev1.Pod
+
+	render := func() {
+		selector, err := labels.Parse(labelFilter)
+		if err != nil {
+			setText(err.Error(), "red")
+			return
+		}
+
+		shown = nil
+		for _, informer := range podInformers {
+			for _, obj := range informer.GetStore().List() {
+				p := obj.(*corev1.Pod)
+				if strings.Contains(p.Name, nameFilter) && selector.Matches(labels.Set(p.Labels)) {
+					shown = append(shown, p)
+				}
+			}
+		}
+		sort.Slice(shown, func(a, b int) bool {
+			if shown[a].Namespace != shown[b].Namespace {
+				return shown[a].Namespace < shown[b].Namespace
+			}
+			return shown[a].Name < shown[b].Name
+		})
+
+		podTable.Clear()
+		setTableHeader(podTable, podHeaders)
+		for n, p := range shown {
+			row := podRow(p)
+			color := podStatusColor(row[2])
+			for col, value := range row {
+				podTable.SetCell(n+1, col, tview.NewTableCell(value).SetTextColor(color))
+			}
+		}
+		podTable.SetTitle(fmt.Sprintf(" Pods (%d) ", len(shown)))
+	}
+
+	watch := func() {
+		stop := startWatch()
+		podInformers = v.podInformers(splitList(namespaces))
+		watchInformers(stop, render, podInformers...)
+		render()
+	}
+
+	podForm.Clear(true)
+	podForm.SetBorder(true).
+		SetTitle(" Pods ").
+		SetTitleAlign(tview.AlignCenter).
+		SetTitleColor(tcell.ColorGreen)
+
+	podForm.AddInputField("Namespaces: ", namespaces, 40, nil, func(s string) {
+		namespaces = s
+	}).AddInputField("Name: ", nameFilter, 40, nil, func(s string) {
+		nameFilter = s
+		render()
+	}).AddInputField("Label Selector: ", labelFilter, 40, nil, func(s string) {
+		labelFilter = s
+	}).AddButton("Return to Tools", func() {
+		showTools()
+	}).AddButton("Apply", func() {
+		watch()
+		app.SetFocus(podTable)
+	})
+
+	podTable.SetBorder(true)
+	podTable.SetFixed(1, 0).
+		SetSelectable(true, false).
+		SetDoneFunc(func(key tcell.Key) {
+			app.SetFocus(podForm)
+		}).
+		SetSelectedFunc(func(row int, column int) {
+			if row > 0 && row <= len(shown) {
+				showPod(shown[row-1])
+				logsMenu(shown[row-1])
+			}
+		})
+
+	podPage.Clear().
+		SetDirection(tview.FlexRow).
+		AddItem(podForm, 9, 0, false).
+		AddItem(podTable, 0, 1, true)
+
+	watch()
+	pages.AddAndSwitchToPage("Pods", podPage, true)
+	app.SetFocus(podTable)
+	setText("Select a pod to show its logs. Leave Namespaces empty to watch all namespaces, Esc moves to the filters.", "white")
+}
+
+// Prints the containers of a pod and their state.
+func showPod(p *corev1.Pod) {
+
+	lines := []string{p.Namespace + "/" + p.Name + "  " + podStatus(p) + "  node: " + p.Spec.NodeName}
+	statuses := append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+	for _, c := range statuses {
+		state := "running"
+		switch {
+		case c.State.Waiting != nil:
+			state = "waiting: " + c.State.Waiting.Reason + " " + c.State.Waiting.Message
+		case c.State.Terminated != nil:
+			state = "terminated: " + c.State.Terminated.Reason + " " + c.State.Terminated.Message
+		}
+		lines = append(lines, fmt.Sprintf("  %s  %s  restarts: %d  %s", c.Name, c.Image, c.RestartCount, state))
+	}
+	setText(strings.Join(lines, "\n"), "white")
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+const PREFLIGHT_REPORT_PATH = "preflight-report.json"
+
+const (
+	CHECK_PASS = "pass"
+	CHECK_WARN = "warn"
+	CHECK_FAIL = "fail"
+)
+
+// Minimums the cluster is checked against, entered in the preflight form.
+type preflightConfig struct {
+	minVersion string
+	maxVersion string
+	minNodes   int
+	minCPU     resource.Quantity
+	minMemory  resource.Quantity
+	namespace  string
+	pullSecret string
+}
+
+type checkResult struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+func (c checkResult) String() string {
+	s := fmt.Sprintf("%-5s %s: %s", strings.ToUpper(c.Status), c.Name, c.Message)
+	if c.Status != CHECK_PASS && c.Remediation != "" {
+		s += "\n      -> " + c.Remediation
+	}
+	return s
+}
+
+// Runs every readiness check against the cluster.
+func (v *Versions) preflight(cfg preflightConfig) []checkResult {
+	return []checkResult{
+		v.checkServerVersion(cfg),
+		v.checkStorageClass(),
+		v.checkIngress(),
+		v.checkNodes(cfg),
+		v.checkCoreDNS(),
+		v.checkNamespace(cfg),
+		v.checkPullSecret(cfg),
+	}
+}
+
+func (v *Versions) checkServerVersion(cfg preflightConfig) checkResult {
+
+	c := checkResult{Name: "Kubernetes version", Remediation: fmt.Sprintf("Use a cluster running Kubernetes %s to %s.", cfg.minVersion, cfg.maxVersion)}
+
+	info, err := v.clientset.Discovery().ServerVersion()
+	if err != nil {
+		c.Status, c.Message = CHECK_FAIL, err.Error()
+		return c
+	}
+	server, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		c.Status, c.Message = CHECK_WARN, "could not parse server version "+info.GitVersion
+		return c
+	}
+	min, err := version.ParseGeneric(cfg.minVersion)
+	if err != nil {
+		c.Status, c.Message = CHECK_FAIL, "invalid minimum version: "+err.Error()
+		return c
+	}
+	max, err := version.ParseGeneric(cfg.maxVersion)
+	if err != nil {
+		c.Status, c.Message = CHECK_FAIL, "invalid maximum version: "+err.Error()
+		return c
+	}
+
+	// Only the minor version is compared against the maximum so that any
+	// patch release of it is accepted
+	switch {
+	case server.LessThan(min):
+		c.Status, c.Message = CHECK_FAIL, info.GitVersion+" is older than "+cfg.minVersion
+	case server.Major() > max.Major() || server.Major() == max.Major() && server.Minor() > max.Minor():
+		c.Status, c.Message = CHECK_WARN, info.GitVersion+" is newer than "+cfg.maxVersion
+	default:
+		c.Status, c.Message = CHECK_PASS, info.GitVersion
+	}
+	return c
+}
+
+func (v *Versions) checkStorageClass() checkResult {
+
+	c := checkResult{Name: "Default StorageClass", Remediation: "Mark a StorageClass as default with the storageclass.kubernetes.io/is-default-class annotation."}
+
+	list, err := v.clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		c.Status, c.Message = CHECK_FAIL, err.Error()
+		return c
+	}
+
+	var defaults []string
+	for _, sc := range list.Items {
+		if sc.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" ||
+			sc.Annotations["storageclass.beta.kubernetes.io/is-default-class"] == "true" {
+			defaults = append(defaults, sc.Name+" ("+sc.Provisioner+")")
+		}
+	}
+
+	switch len(defaults) {
+	case 0:
+		c.Status, c.Message = CHECK_FAIL, fmt.Sprintf("none of the %d storage classes is the default", len(list.Items))
+	case 1:
+		c.Status, c.Message = CHECK_PASS, defaults[0]
+	default:
+		c.Status, c.Message = CHECK_WARN, "more than one default: "+strings.Join(defaults, ", ")
+		c.Remediation = "Keep the default annotation on a single StorageClass."
+	}
+	return c
+}
+
+func (v *Versions) checkIngress() checkResult {
+
+	c := checkResult{Name: "Ingress controller", Remediation: "Install an ingress controller, for example ingress-nginx, or configure cnvrg to use Istio or NodePort."}
+
+	classes, err := v.clientset.NetworkingV1().IngressClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		c.Status, c.Message = CHECK_FAIL, err.Error()
+		return c
+	}
+	if len(classes.Items) > 0 {
+		var names []string
+		for _, ic := range classes.Items {
+			names = append(names, ic.Name+" ("+ic.Spec.Controller+")")
+		}
+		c.Status, c.Message = CHECK_PASS, strings.Join(names, ", ")
+		return c
+	}
+
+	// Older controllers do not register an IngressClass
+	pods, err := v.clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: "app.kubernetes.io/component=controller"})
+	if err == nil {
+		for _, p := range pods.Items {
+			if strings.Contains(p.Name, "ingress") {
+				c.Status, c.Message = CHECK_WARN, "no IngressClass found, but pod "+p.Namespace+"/"+p.Name+" looks like an ingress controller"
+				return c
+			}
+		}
+	}
+	c.Status, c.Message = CHECK_FAIL, "no IngressClass or ingress controller found"
+	return c
+}
+
+func (v *Versions) checkNodes(cfg preflightConfig) checkResult {
+
+	c := checkResult{Name: "Nodes and resources"}
+
+	nodes, err := v.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		c.Status, c.Message = CHECK_FAIL, err.Error()
+		return c
+	}
+
+	ready := 0
+	cpu, memory := resource.Quantity{}, resource.Quantity{}
+	for _, n := range nodes.Items {
+		for _, cond := range n.Status.Conditions {
+			if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue {
+				ready++
+				cpu.Add(n.Status.Allocatable[corev1.ResourceCPU])
+				memory.Add(n.Status.Allocatable[corev1.ResourceMemory])
+			}
+		}
+	}
+
+	c.Message = fmt.Sprintf("%d of %d nodes ready, allocatable %s CPU and %s memory", ready, len(nodes.Items), cpu.String(), memory.String())
+	switch {
+	case ready < cfg.minNodes:
+		c.Status = CHECK_FAIL
+		c.Remediation = fmt.Sprintf("At least %d ready nodes are required.", cfg.minNodes)
+	case cpu.Cmp(cfg.minCPU) < 0 || memory.Cmp(cfg.minMemory) < 0:
+		c.Status = CHECK_FAIL
+		c.Remediation = fmt.Sprintf("At least %s CPU and %s memory are required, add nodes or use larger nodes.", cfg.minCPU.String(), cfg.minMemory.String())
+	case ready < len(nodes.Items):
+		c.Status = CHECK_WARN
+		c.Remediation = "Check the nodes that are not ready on the Nodes page."
+	default:
+		c.Status = CHECK_PASS
+	}
+	return c
+}
+
+func (v *Versions) checkCoreDNS() checkResult {
+
+	c := checkResult{Name: "CoreDNS", Remediation: "Check the kube-dns deployment and its pods in kube-system."}
+
+	list, err := v.clientset.AppsV1().Deployments("kube-system").List(ctx, metav1.ListOptions{LabelSelector: "k8s-app=kube-dns"})
+	if err != nil {
+		c.Status, c.Message = CHECK_FAIL, err.Error()
+		return c
+	}
+	if len(list.Items) == 0 {
+		c.Status, c.Message = CHECK_WARN, "no deployment labeled k8s-app=kube-dns in kube-system"
+		return c
+	}
+
+	d := list.Items[0]
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	c.Message = fmt.Sprintf("%s ready %d/%d", d.Name, d.Status.ReadyReplicas, replicas)
+	switch {
+	case d.Status.ReadyReplicas == 0:
+		c.Status = CHECK_FAIL
+	case d.Status.ReadyReplicas < replicas:
+		c.Status = CHECK_WARN
+	default:
+		c.Status = CHECK_PASS
+	}
+	return c
+}
+
+func (v *Versions) checkNamespace(cfg preflightConfig) checkResult {
+
+	c := checkResult{Name: "Namespace " + cfg.namespace, Remediation: "Create it with: kubectl create namespace " + cfg.namespace}
+
+	_, err := v.clientset.CoreV1().Namespaces().Get(ctx, cfg.namespace, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		c.Status, c.Message = CHECK_WARN, "does not exist yet"
+	case err != nil:
+		c.Status, c.Message = CHECK_FAIL, err.Error()
+	default:
+		c.Status, c.Message = CHECK_PASS, "exists"
+	}
+	return c
+}
+
+func (v *Versions) checkPullSecret(cfg preflightConfig) checkResult {
+
+	c := checkResult{Name: "Pull secret " + cfg.pullSecret, Remediation: "Create it with Tools > Create Pull Secret."}
+
+	secret, err := v.clientset.CoreV1().Secrets(cfg.namespace).Get(ctx, cfg.pullSecret, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		c.Status, c.Message = CHECK_FAIL, "not found in "+cfg.namespace
+	case err != nil:
+		c.Status, c.Message = CHECK_FAIL, err.Error()
+	case secret.Type != corev1.SecretTypeDockerConfigJson:
+		c.Status, c.Message = CHECK_WARN, "has type "+string(secret.Type)+" instead of "+string(corev1.SecretTypeDockerConfigJson)
+	default:
+		c.Status, c.Message = CHECK_PASS, "exists in "+cfg.namespace
+	}
+	return c
+}
+
+func writePreflightReport(results []checkResult) error {
+	report, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(PREFLIGHT_REPORT_PATH, report, 0644)
+}
+
+// Form with the minimums for the preflight checks.
+func preflightMenu() {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	minVersion, maxVersion := "1.24", "1.30"
+	minNodes, minCPU, minMemory := "3", "24", "64Gi"
+	namespace, pullSecret := v.appNamespace(), DEFAULT_PULL_SECRET
+	var results []checkResult
+
+	run := func() {
+		nodes, err := strconv.Atoi(minNodes)
+		if err != nil {
+			setText("Min Nodes must be a number.", "red")
+			return
+		}
+		cpu, err := resource.ParseQuantity(minCPU)
+		if err != nil {
+			setText("Min CPU: "+err.Error(), "red")
+			return
+		}
+		memory, err := resource.ParseQuantity(minMemory)
+		if err != nil {
+			setText("Min Memory: "+err.Error(), "red")
+			return
+		}
+
+		cfg := preflightConfig{minVersion, maxVersion, nodes, cpu, memory, namespace, pullSecret}
+		setText("Running preflight checks...", "white")
+		go func() {
+			result := v.preflight(cfg)
+			// Export reads the results on the UI goroutine
+			app.QueueUpdate(func() {
+				results = result
+			})
+			var lines []string
+			color := "green"
+			for _, r := range result {
+				lines = append(lines, r.String())
+				if r.Status == CHECK_FAIL {
+					color = "red"
+				}
+			}
+			setText(strings.Join(lines, "\n"), color)
+		}()
+	}
+
+	f := showToolForm("Preflight Checks")
+	f.AddInputField("Min Kubernetes Version: ", minVersion, 10, nil, func(s string) {
+		minVersion = s
+	}).AddInputField("Max Kubernetes Version: ", maxVersion, 10, nil, func(s string) {
+		maxVersion = s
+	}).AddInputField("Min Nodes: ", minNodes, 10, nil, func(s string) {
+		minNodes = s
+	}).AddInputField("Min CPU: ", minCPU, 10, nil, func(s string) {
+		minCPU = s
+	}).AddInputField("Min Memory: ", minMemory, 10, nil, func(s string) {
+		minMemory = s
+	}).AddInputField("cnvrg Namespace: ", namespace, 40, nil, func(s string) {
+		namespace = s
+	}).AddInputField("Pull Secret: ", pullSecret, 40, nil, func(s string) {
+		pullSecret = s
+	}).AddButton("Run Checks", func() {
+		run()
+	}).AddButton("Export", func() {
+		if results == nil {
+			setText("Please run the checks first.", "red")
+			return
+		}
+		if err := writePreflightReport(results); err != nil {
+			handlePanic(err)
+			return
+		}
+		setText("Preflight report written to "+PREFLIGHT_REPORT_PATH, "green")
+	})
+
+	setText("Checks that the cluster is ready for installing cnvrg from the private registry.", "white")
+}
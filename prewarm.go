@@ -0,0 +1,313 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	PREWARM_NAME         = "cnvrg-image-prewarm"
+	DEFAULT_PREWARM_TOOL = "busybox:1.36"
+	PREWARM_TOOL_PATH    = "/prewarm/busybox"
+)
+
+// Closed to cancel the running pre-warm, only the pre-warm page and the app
+// exit control it. prewarmDone is closed once its DaemonSet was deleted.
+var prewarmStop, prewarmDone chan struct{}
+
+// Cancels the running pre-warm. With wait it returns once the DaemonSet was
+// deleted, so quitting the app does not leave it on the nodes.
+func stopPrewarm(wait bool) {
+	if prewarmStop == nil {
+		return
+	}
+	close(prewarmStop)
+	if wait {
+		select {
+		case <-prewarmDone:
+		case <-time.After(30 * time.Second):
+			ErrorLogger.Println("timed out deleting the pre-warm daemonset")
+		}
+	}
+	prewarmStop, prewarmDone = nil, nil
+}
+
+// Returns the DaemonSet pulling the images on every selected node. Images may
+// not have a shell, so a static busybox is copied into a shared volume first
+// and every image runs it as its init container. Init containers run one after
+// another, an image that fails to pull blocks the ones after it on that node.
+// Tainted nodes, the control plane included, are only used with tolerate.
+func prewarmDaemonSet(namespace string, images []string, tool string, nodeSelector map[string]string, pullSecret string, tolerate bool) *appsv1.DaemonSet {
+
+	podLabels := map[string]string{"app": PREWARM_NAME}
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("10m"),
+		corev1.ResourceMemory: resource.MustParse("16Mi"),
+	}
+	mount := []corev1.VolumeMount{{Name: "prewarm", MountPath: "/prewarm"}}
+
+	initContainers := []corev1.Container{{
+		Name:         "copy-busybox",
+		Image:        tool,
+		Command:      []string{"cp", "/bin/busybox", PREWARM_TOOL_PATH},
+		VolumeMounts: mount,
+		Resources:    corev1.ResourceRequirements{Requests: requests},
+	}}
+	for n, image := range images {
+		initContainers = append(initContainers, corev1.Container{
+			Name:            fmt.Sprintf("pull-%d", n),
+			Image:           image,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Command:         []string{PREWARM_TOOL_PATH, "true"},
+			VolumeMounts:    mount,
+			Resources:       corev1.ResourceRequirements{Requests: requests},
+		})
+	}
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: PREWARM_NAME, Namespace: namespace, Labels: podLabels},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: podLabels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+				Spec: corev1.PodSpec{
+					NodeSelector:   nodeSelector,
+					InitContainers: initContainers,
+					Containers: []corev1.Container{{
+						Name:      "done",
+						Image:     tool,
+						Command:   []string{"sleep", "2147483647"},
+						Resources: corev1.ResourceRequirements{Requests: requests},
+					}},
+					Volumes: []corev1.Volume{{
+						Name:         "prewarm",
+						VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+					}},
+				},
+			},
+		},
+	}
+	if pullSecret != "" {
+		ds.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: pullSecret}}
+	}
+	if tolerate {
+		ds.Spec.Template.Spec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+	}
+	return ds
+}
+
+// Returns the number of images pulled by a pre-warm pod and the pull error
+// blocking it, if any. The init containers run one after another, so an image
+// is pulled once its init container started.
+func prewarmProgress(p *corev1.Pod) (int, string) {
+
+	pulled, failure := 0, ""
+	for _, s := range p.Status.InitContainerStatuses {
+		if s.Name == "copy-busybox" {
+			if s.State.Waiting != nil && pullFailures[s.State.Waiting.Reason] {
+				failure = s.State.Waiting.Reason + ": " + s.Image
+			}
+			continue
+		}
+		switch {
+		case s.State.Running != nil, s.State.Terminated != nil:
+			pulled++
+		case s.State.Waiting != nil && pullFailures[s.State.Waiting.Reason]:
+			failure = s.State.Waiting.Reason + ": " + s.Image
+		}
+	}
+	return pulled, failure
+}
+
+// Deletes the pre-warm DaemonSet. When uid is set only that DaemonSet is
+// deleted, so a run that was replaced does not delete the next one.
+func (v *Versions) deletePrewarm(namespace string, uid types.UID) {
+	opts := metav1.DeleteOptions{}
+	if uid != "" {
+		opts.Preconditions = &metav1.Preconditions{UID: &uid}
+	}
+	err := v.clientset.AppsV1().DaemonSets(namespace).Delete(ctx, PREWARM_NAME, opts)
+	if err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+		ErrorLogger.Println("deleting the pre-warm daemonset:", err)
+		return
+	}
+	InfoLogger.Println("Deleted the pre-warm daemonset in", namespace)
+}
+
+// Creates the DaemonSet and follows the pulls on every node until all nodes
+// are done or blocked by a failed pull, the timeout expires or stop is closed. The DaemonSet is deleted in
+// every case, then done is closed.
+func (v *Versions) prewarm(stop chan struct{}, done chan struct{}, ds *appsv1.DaemonSet, timeout time.Duration) {
+	InfoLogger.Println("In the prewarm function")
+
+	defer close(done)
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	ns := ds.Namespace
+	images := len(ds.Spec.Template.Spec.InitContainers) - 1
+
+	// A DaemonSet left by an interrupted run is replaced
+	v.deletePrewarm(ns, "")
+	for n := 0; n < 30; n++ {
+		if _, err := v.clientset.AppsV1().DaemonSets(ns).Get(ctx, PREWARM_NAME, metav1.GetOptions{}); errors.IsNotFound(err) {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	created, err := v.clientset.AppsV1().DaemonSets(ns).Create(ctx, ds, metav1.CreateOptions{})
+	if err != nil {
+		panic(err)
+	}
+	defer v.deletePrewarm(ns, created.UID)
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+	for {
+		select {
+		case <-stop:
+			setText("Pre-warming cancelled, the daemonset was deleted.", "white")
+			return
+		default:
+		}
+
+		current, err := v.clientset.AppsV1().DaemonSets(ns).Get(ctx, PREWARM_NAME, metav1.GetOptions{})
+		if err != nil {
+			panic(err)
+		}
+		pods, err := v.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{LabelSelector: "app=" + PREWARM_NAME})
+		if err != nil {
+			panic(err)
+		}
+
+		var lines []string
+		done, blocked := 0, 0
+		for _, p := range pods.Items {
+			pulled, failure := prewarmProgress(&p)
+			node := p.Spec.NodeName
+			if node == "" {
+				node = p.Name + " (not scheduled)"
+			}
+			line := fmt.Sprintf("%s: %d/%d images", node, pulled, images)
+			switch {
+			case p.Status.Phase == corev1.PodRunning:
+				line += " done"
+				done++
+			case failure != "":
+				line += "  " + failure + " (blocks the images after it)"
+				blocked++
+			}
+			lines = append(lines, line)
+		}
+		sort.Strings(lines)
+
+		desired := int(current.Status.DesiredNumberScheduled)
+		header := fmt.Sprintf("Pre-warming %d images: %d of %d nodes done, %s elapsed", images, done, desired, time.Since(start).Round(time.Second))
+		lines = append([]string{header}, lines...)
+
+		switch {
+		case desired > 0 && done == desired:
+			setText(strings.Join(lines, "\n")+"\n\nAll nodes pulled the images, the daemonset was deleted.", "green")
+			return
+		case desired > 0 && blocked > 0 && done+blocked == desired:
+			setText(strings.Join(lines, "\n")+"\n\nThe remaining nodes are blocked by a failed pull, the daemonset was deleted.", "red")
+			return
+		case time.Now().After(deadline):
+			setText(strings.Join(lines, "\n")+"\n\nTimed out, the daemonset was deleted.", "red")
+			return
+		}
+		setText(strings.Join(lines, "\n"), "white")
+		time.Sleep(3 * time.Second)
+	}
+}
+
+// Form to pull the images of the push targets on the nodes ahead of time.
+func prewarmMenu(i *Images) {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	namespace, pullSecret := v.appNamespace(), DEFAULT_PULL_SECRET
+	tool := i.target.rewriteImage(DEFAULT_PREWARM_TOOL)
+	if i.target.registry == "" {
+		tool = DEFAULT_PREWARM_TOOL
+	}
+	selector, tolerate, timeout := "", false, "1800"
+
+	f := showToolForm("Pre-warm Node Images")
+	f.AddInputField("Namespace: ", namespace, 40, nil, func(s string) {
+		namespace = s
+	}).AddInputField("Pull Secret: ", pullSecret, 40, nil, func(s string) {
+		pullSecret = s
+	}).AddInputField("Busybox Image: ", tool, 40, nil, func(s string) {
+		tool = s
+	}).AddInputField("Node Selector: ", selector, 40, nil, func(s string) {
+		selector = s
+	}).AddCheckbox("Tolerate Taints: ", tolerate, func(checked bool) {
+		tolerate = checked
+	}).AddInputField("Timeout (s): ", timeout, 10, nil, func(s string) {
+		timeout = s
+	}).AddButton("Start", func() {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil {
+			setText("Timeout must be a number.", "red")
+			return
+		}
+		nodeSelector, err := labels.ConvertSelectorToLabelsMap(selector)
+		if err != nil {
+			setText("Node Selector must be a list of key=value labels: "+err.Error(), "red")
+			return
+		}
+
+		targets, err := i.configuredTargets()
+		if err != nil {
+			setText(err.Error(), "red")
+			return
+		}
+		seen := map[string]bool{}
+		var images []string
+		for _, t := range targets {
+			_, target, err := i.imageMapping(t)
+			if err != nil {
+				handlePanic(err)
+				return
+			}
+			for _, image := range target {
+				if !seen[image] {
+					seen[image] = true
+					images = append(images, image)
+				}
+			}
+		}
+		if len(images) == 0 {
+			setText("There are no images to pre-warm, set the images file and push target first.", "red")
+			return
+		}
+
+		ds := prewarmDaemonSet(namespace, images, tool, nodeSelector, pullSecret, tolerate)
+		stopPrewarm(false)
+		prewarmStop, prewarmDone = make(chan struct{}), make(chan struct{})
+		go v.prewarm(prewarmStop, prewarmDone, ds, time.Duration(seconds)*time.Second)
+	}).AddButton("Cancel", func() {
+		stopPrewarm(false)
+	})
+
+	setText("Runs a temporary daemonset with an init container per image so the nodes pull them ahead of time. The busybox image must be reachable from the nodes, it defaults to the push target. An image failing to pull blocks the images after it on that node, the run stops once every node is done or blocked. Tainted nodes, the control plane included, are only used when taints are tolerated. Cancel or quitting the tool deletes the daemonset.", "white")
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const PULL_CHECK_LABEL = "cnvrg-dep-tool/pull-check"
+
+// Waiting reasons of a container whose image could not be pulled.
+var pullFailures = map[string]bool{
+	"ErrImagePull":        true,
+	"ImagePullBackOff":    true,
+	"InvalidImageName":    true,
+	"ErrImageNeverPull":   true,
+	"RegistryUnavailable": true,
+}
+
+// Outcome of pulling one image on one node.
+type pullCheck struct {
+	pod    string
+	image  string
+	node   string
+	done   bool
+	passed bool
+	reason string
+}
+
+// Creates the pod unless a pod with the same name exists already.
+func (v *Versions) createPod(pod *corev1.Pod) error {
+
+	if v.checkPodExists(pod.Name, pod.Namespace) {
+		return fmt.Errorf("pod %s/%s already exists", pod.Namespace, pod.Name)
+	}
+	_, err := v.clientset.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	return err
+}
+
+func (v *Versions) checkPodExists(name string, namespace string) bool {
+	_, err := v.clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	return err == nil
+}
+
+// Pod pulling a single image. The container only has to start, so its command
+// may fail when the image has no shell.
+func pullCheckPod(name string, namespace string, image string, node string, nodeSelector map[string]string, pullSecret string, run string) *corev1.Pod {
+
+	grace := int64(0)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{PULL_CHECK_LABEL: run},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			TerminationGracePeriodSeconds: &grace,
+			NodeSelector:                  nodeSelector,
+			Containers: []corev1.Container{{
+				Name:            "pull",
+				Image:           image,
+				ImagePullPolicy: corev1.PullAlways,
+				Command:         []string{"true"},
+			}},
+		},
+	}
+	if pullSecret != "" {
+		pod.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: pullSecret}}
+	}
+	if node != "" {
+		// Bypasses the scheduler, taints do not keep the pod off the node
+		pod.Spec.NodeName = node
+		pod.Spec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+	}
+	return pod
+}
+
+// Updates the check from the state of its pod.
+func (c *pullCheck) update(p *corev1.Pod) {
+
+	if c.node == "" {
+		c.node = p.Spec.NodeName
+	}
+	for _, s := range p.Status.ContainerStatuses {
+		switch {
+		case s.State.Waiting != nil && pullFailures[s.State.Waiting.Reason]:
+			c.done, c.passed = true, false
+			c.reason = s.State.Waiting.Reason + ": " + s.State.Waiting.Message
+		case s.State.Running != nil, s.State.Terminated != nil:
+			c.done, c.passed, c.reason = true, true, ""
+		case s.State.Waiting != nil && s.State.Waiting.Reason != "ContainerCreating":
+			// The image is present, the container failed to be created
+			c.done, c.passed, c.reason = true, true, ""
+		}
+	}
+	if c.done {
+		return
+	}
+	for _, cond := range p.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+			c.reason = "not scheduled: " + cond.Message
+		}
+	}
+}
+
+// Lists the ready nodes matching the label selector.
+func (v *Versions) readyNodes(selector string) ([]string, error) {
+
+	list, err := v.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	var nodes []string
+	for _, n := range list.Items {
+		for _, cond := range n.Status.Conditions {
+			if cond.Type == corev1.NodeReady && cond.Status == corev1.ConditionTrue && !n.Spec.Unschedulable {
+				nodes = append(nodes, n.Name)
+			}
+		}
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+// Starts a pod per image, or per image and node, waits for the pulls to finish
+// and deletes the pods. Without nodes the scheduler places the pods on a node
+// matching nodeSelector.
+func (v *Versions) pullCheck(namespace string, images []string, nodes []string, nodeSelector map[string]string, pullSecret string, timeout time.Duration) {
+	InfoLogger.Println("In the pullCheck function")
+
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	run := strconv.FormatInt(time.Now().Unix(), 10)
+	if len(nodes) == 0 {
+		nodes = []string{""}
+	}
+
+	var checks []*pullCheck
+	for n, image := range images {
+		for m, node := range nodes {
+			c := &pullCheck{pod: fmt.Sprintf("cnvrg-pull-check-%s-%d-%d", run, n, m), image: image, node: node}
+			if err := v.createPod(pullCheckPod(c.pod, namespace, image, node, nodeSelector, pullSecret, run)); err != nil {
+				c.done, c.reason = true, err.Error()
+			}
+			checks = append(checks, c)
+		}
+	}
+
+	defer func() {
+		grace := int64(0)
+		err := v.clientset.CoreV1().Pods(namespace).DeleteCollection(ctx, metav1.DeleteOptions{GracePeriodSeconds: &grace},
+			metav1.ListOptions{LabelSelector: PULL_CHECK_LABEL + "=" + run})
+		if err != nil && !errors.IsNotFound(err) {
+			ErrorLogger.Println("pull check cleanup:", err)
+		}
+	}()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		pending := 0
+		for _, c := range checks {
+			if c.done {
+				continue
+			}
+			p, err := v.clientset.CoreV1().Pods(namespace).Get(ctx, c.pod, metav1.GetOptions{})
+			if err != nil {
+				c.reason = err.Error()
+			} else {
+				c.update(p)
+			}
+			if !c.done {
+				pending++
+			}
+		}
+		if pending == 0 || time.Now().After(deadline) {
+			break
+		}
+		setText(fmt.Sprintf("Pulling %d images, %d of %d pods pending...", len(images), pending, len(checks)), "white")
+		time.Sleep(2 * time.Second)
+	}
+
+	setText(pullCheckReport(checks), pullCheckColor(checks))
+}
+
+// Groups the results by image, with a line per node.
+func pullCheckReport(checks []*pullCheck) string {
+
+	var lines []string
+	image := ""
+	for _, c := range checks {
+		if c.image != image {
+			image = c.image
+			lines = append(lines, image)
+		}
+		node := c.node
+		if node == "" {
+			node = "<unscheduled>"
+		}
+		switch {
+		case c.passed:
+			lines = append(lines, "  PASS "+node)
+		case c.done:
+			lines = append(lines, "  FAIL "+node+": "+c.reason)
+		default:
+			lines = append(lines, "  TIMEOUT "+node+": "+c.reason)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func pullCheckColor(checks []*pullCheck) string {
+	for _, c := range checks {
+		if !c.passed {
+			return "red"
+		}
+	}
+	return "green"
+}
+
+// Form to test that the cluster can pull the pushed images.
+func pullCheckMenu(i *Images) {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	namespace, pullSecret := v.appNamespace(), DEFAULT_PULL_SECRET
+	perNode, selector, timeout := false, "", "300"
+
+	f := showToolForm("Cluster Pull Check")
+	f.AddInputField("Namespace: ", namespace, 40, nil, func(s string) {
+		namespace = s
+	}).AddInputField("Pull Secret: ", pullSecret, 40, nil, func(s string) {
+		pullSecret = s
+	}).AddCheckbox("One Pod per Node: ", perNode, func(checked bool) {
+		perNode = checked
+	}).AddInputField("Node Selector: ", selector, 40, nil, func(s string) {
+		selector = s
+	}).AddInputField("Timeout (s): ", timeout, 10, nil, func(s string) {
+		timeout = s
+	}).AddButton("Run", func() {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil {
+			setText("Timeout must be a number.", "red")
+			return
+		}
+
+		targets, err := i.configuredTargets()
+		if err != nil {
+			setText(err.Error(), "red")
+			return
+		}
+		var images []string
+		for _, t := range targets {
+			_, target, err := i.imageMapping(t)
+			if err != nil {
+				handlePanic(err)
+				return
+			}
+			images = append(images, target...)
+		}
+		if len(images) == 0 {
+			setText("There are no images to check, set the images file and push target first.", "red")
+			return
+		}
+
+		var nodes []string
+		var nodeSelector map[string]string
+		if perNode {
+			nodes, err = v.readyNodes(selector)
+			if err != nil {
+				handlePanic(err)
+				return
+			}
+			if len(nodes) == 0 {
+				setText("No ready nodes match the selector.", "red")
+				return
+			}
+		} else {
+			nodeSelector, err = labels.ConvertSelectorToLabelsMap(selector)
+			if err != nil {
+				setText("Node Selector must be a list of key=value labels: "+err.Error(), "red")
+				return
+			}
+		}
+		go v.pullCheck(namespace, images, nodes, nodeSelector, pullSecret, time.Duration(seconds)*time.Second)
+	})
+
+	setText("Starts short-lived pods that pull the images of the push targets from inside the cluster. The pods are deleted when the check ends.", "white")
+}
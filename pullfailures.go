@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/distribution/reference"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Causes of pull failures in the order they are reported. A message is put in
+// the first cause with a matching pattern. Docker Hub says a denied repository
+// "does not exist or may require 'docker login'", so access is checked first
+// and only images surely missing are appended to the images file.
+var pullCauses = []struct {
+	name     string
+	patterns []string
+}{
+	{"unauthorized", []string{"pull access denied", "unauthorized", "authentication required", "access denied", "denied", "forbidden"}},
+	{"not found", []string{"not found", "manifest unknown", "name unknown", "does not exist"}},
+	{"TLS", []string{"x509", "tls", "certificate"}},
+	{"timeout", []string{"timeout", "timed out", "deadline exceeded", "connection refused", "no such host", "no route to host"}},
+}
+
+const OTHER_CAUSE = "other"
+
+// An image reference that fails to pull and the pods affected by it.
+type pullFailure struct {
+	image   string
+	cause   string
+	message string
+	pods    []string
+}
+
+func pullCause(message string) string {
+	lower := strings.ToLower(message)
+	for _, c := range pullCauses {
+		for _, p := range c.patterns {
+			if strings.Contains(lower, p) {
+				return c.name
+			}
+		}
+	}
+	return OTHER_CAUSE
+}
+
+// Lists the images that fail to pull in the namespaces. The back-off message
+// of the kubelet is replaced by the error of the last failed pull event.
+func (v *Versions) scanPullFailures(namespaces []string) ([]*pullFailure, error) {
+
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	failures := map[string]*pullFailure{}
+	for _, ns := range namespaces {
+		pods, err := v.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		events, err := v.clientset.CoreV1().Events(ns).List(ctx, metav1.ListOptions{FieldSelector: "involvedObject.kind=Pod,reason=Failed"})
+		if err != nil {
+			return nil, err
+		}
+
+		// Latest pull error per pod and image
+		pullErrors := map[string]string{}
+		seen := map[string]time.Time{}
+		for n := range events.Items {
+			e := &events.Items[n]
+			if !strings.Contains(e.Message, "pull") {
+				continue
+			}
+			for _, p := range pods.Items {
+				if p.Namespace != e.InvolvedObject.Namespace || p.Name != e.InvolvedObject.Name {
+					continue
+				}
+				if e.InvolvedObject.UID != "" && p.UID != e.InvolvedObject.UID {
+					continue
+				}
+				for _, c := range append(append([]corev1.Container{}, p.Spec.InitContainers...), p.Spec.Containers...) {
+					key := p.Name + "/" + c.Image
+					if last := eventLastSeen(e); strings.Contains(e.Message, c.Image) && last.After(seen[key]) {
+						pullErrors[key], seen[key] = e.Message, last
+					}
+				}
+			}
+		}
+
+		for _, p := range pods.Items {
+			statuses := append(append([]corev1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+			for _, c := range statuses {
+				if c.State.Waiting == nil || !pullFailures[c.State.Waiting.Reason] {
+					continue
+				}
+				message := c.State.Waiting.Message
+				if detail, ok := pullErrors[p.Name+"/"+c.Image]; ok {
+					message = detail
+				}
+				f, ok := failures[c.Image]
+				if !ok {
+					f = &pullFailure{image: c.Image, cause: pullCause(message), message: message}
+					failures[c.Image] = f
+				}
+				f.pods = append(f.pods, p.Namespace+"/"+p.Name)
+			}
+		}
+	}
+
+	var result []*pullFailure
+	for _, f := range failures {
+		result = append(result, f)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].image < result[b].image
+	})
+	return result, nil
+}
+
+// Formats the failures grouped by cause.
+func pullFailureReport(failures []*pullFailure) string {
+
+	causes := []string{}
+	for _, c := range pullCauses {
+		causes = append(causes, c.name)
+	}
+	causes = append(causes, OTHER_CAUSE)
+
+	var lines []string
+	for _, cause := range causes {
+		var group []string
+		for _, f := range failures {
+			if f.cause == cause {
+				group = append(group, "  "+f.image, "    "+f.message, "    pods: "+strings.Join(f.pods, ", "))
+			}
+		}
+		if len(group) > 0 {
+			lines = append(lines, strings.ToUpper(cause)+":")
+			lines = append(lines, group...)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Returns the normalized form of a reference, the reference itself when it
+// can not be parsed.
+func normalizeImage(image string) string {
+	if named, err := reference.ParseNormalizedNamed(image); err == nil {
+		return reference.TagNameOnly(named).String()
+	}
+	return image
+}
+
+// Maps references of the push targets back to the source registry, so the
+// images can be pulled and pushed again. References in the mapping of a
+// target are inverted with its rewrite rule, others starting with the name of
+// a target get the source prefix instead. Other references are kept.
+func (i *Images) sourceImages(images []string, sourcePrefix string) ([]string, error) {
+
+	targets := i.activeTargets()
+	sources := map[string]string{}
+	for _, t := range targets {
+		source, target, err := i.imageMapping(t)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for n := range target {
+			sources[normalizeImage(target[n])] = source[n]
+		}
+	}
+
+	var result []string
+	for _, image := range images {
+		if source, ok := sources[normalizeImage(image)]; ok {
+			result = append(result, source)
+			continue
+		}
+		mapped := image
+		for _, t := range targets {
+			prefix := t.name() + "/"
+			if t.registry != "" && sourcePrefix != "" && strings.HasPrefix(image, prefix) {
+				mapped = strings.TrimSuffix(sourcePrefix, "/") + "/" + strings.TrimPrefix(image, prefix)
+				break
+			}
+		}
+		result = append(result, mapped)
+	}
+	return result, nil
+}
+
+// Appends the images that are not in the images file yet, with a comment
+// saying where they came from. Returns the images added.
+func appendImages(fileName string, images []string, comment string) ([]string, error) {
+
+	existing, err := readFile(fileName)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	listed := map[string]bool{}
+	for _, e := range existing {
+		listed[e] = true
+	}
+
+	var added []string
+	for _, image := range images {
+		if !listed[image] {
+			listed[image] = true
+			added = append(added, image)
+		}
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "\n# %s\n%s\n", comment, strings.Join(added, "\n"))
+	return added, err
+}
+
+// Form to scan the cnvrg namespaces for images that fail to pull.
+func pullFailuresMenu(i *Images) {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	namespaces := strings.Join(v.namespaces(), ", ")
+	sourcePrefix := "docker.io/cnvrg"
+	if images, err := readFile(i.fileName); err == nil && len(images) > 0 {
+		if slash := strings.LastIndex(images[0], "/"); slash > 0 {
+			sourcePrefix = images[0][:slash]
+		}
+	}
+	var failures []*pullFailure
+
+	f := showToolForm("Image Pull Failures")
+	f.AddInputField("Namespaces: ", namespaces, 40, nil, func(s string) {
+		namespaces = s
+	}).AddInputField("Source Prefix: ", sourcePrefix, 40, nil, func(s string) {
+		sourcePrefix = s
+	}).AddButton("Scan", func() {
+		var err error
+		failures, err = v.scanPullFailures(splitList(namespaces))
+		if err != nil {
+			handlePanic(err)
+			return
+		}
+		if len(failures) == 0 {
+			setText("No pods are failing to pull their images.", "green")
+			return
+		}
+		setText(pullFailureReport(failures), "red")
+	}).AddButton("Append Missing", func() {
+		if i.fileName == "" {
+			setText("Please set the images file on the main menu first.", "red")
+			return
+		}
+		var notFound []string
+		for _, f := range failures {
+			if f.cause == "not found" {
+				notFound = append(notFound, f.image)
+			}
+		}
+		if len(notFound) == 0 {
+			setText("No images were reported as not found, run Scan first.", "red")
+			return
+		}
+		missing, err := i.sourceImages(notFound, sourcePrefix)
+		if err != nil {
+			handlePanic(err)
+			return
+		}
+		setText("Images to append:\n"+strings.Join(missing, "\n"), "white")
+		confirm(fmt.Sprintf("Append %d images to %s?", len(missing), i.fileName), func() {
+			added, err := appendImages(i.fileName, missing, "Pull failures found in the cluster on "+time.Now().Format("2006-01-02"))
+			if err != nil {
+				handlePanic(err)
+				return
+			}
+			setText(fmt.Sprintf("Appended %d images to %s:\n%s", len(added), i.fileName, strings.Join(added, "\n")), "green")
+		})
+	})
+
+	setText("Lists the images failing with ErrImagePull or ImagePullBackOff, grouped by cause. Images not found are mapped back to their source image with the rewrite rules of the push targets, or the source prefix, and appended to the images file.", "white")
+}
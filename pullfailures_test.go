@@ -0,0 +1,61 @@
+package main
+
+import "testing"
+
+func TestPullCause(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			name:    "docker hub pull access denied",
+			message: `Failed to pull image "cnvrg/app:v9": rpc error: code = Unknown desc = Error response from daemon: pull access denied for cnvrg/app, repository does not exist or may require 'docker login': denied: requested access to the resource is denied`,
+			want:    "unauthorized",
+		},
+		{
+			name:    "containerd tag not found",
+			message: `Failed to pull image "docker.io/cnvrg/app:v9": rpc error: code = NotFound desc = failed to pull and unpack image "docker.io/cnvrg/app:v9": failed to resolve reference "docker.io/cnvrg/app:v9": docker.io/cnvrg/app:v9: not found`,
+			want:    "not found",
+		},
+		{
+			name:    "manifest unknown",
+			message: `Failed to pull image "registry.local:5000/cnvrg/app:v9": rpc error: code = Unknown desc = Error response from daemon: manifest for registry.local:5000/cnvrg/app:v9 not found: manifest unknown: manifest unknown`,
+			want:    "not found",
+		},
+		{
+			name:    "registry unauthorized",
+			message: `Failed to pull image "registry.local/cnvrg/app:v9": rpc error: code = Unknown desc = failed to pull and unpack image "registry.local/cnvrg/app:v9": failed to resolve reference "registry.local/cnvrg/app:v9": failed to authorize: failed to fetch anonymous token: unexpected status: 401 Unauthorized`,
+			want:    "unauthorized",
+		},
+		{
+			name:    "unknown certificate authority",
+			message: `Failed to pull image "registry.local/cnvrg/app:v9": rpc error: code = Unknown desc = failed to pull and unpack image "registry.local/cnvrg/app:v9": failed to resolve reference "registry.local/cnvrg/app:v9": failed to do request: Head "https://registry.local/v2/cnvrg/app/manifests/v9": tls: failed to verify certificate: x509: certificate signed by unknown authority`,
+			want:    "TLS",
+		},
+		{
+			name:    "unknown host",
+			message: `Failed to pull image "registry.local/cnvrg/app:v9": rpc error: code = Unknown desc = failed to pull and unpack image "registry.local/cnvrg/app:v9": failed to resolve reference "registry.local/cnvrg/app:v9": failed to do request: Head "https://registry.local/v2/cnvrg/app/manifests/v9": dial tcp: lookup registry.local on 10.96.0.10:53: no such host`,
+			want:    "timeout",
+		},
+		{
+			name:    "i/o timeout",
+			message: `Failed to pull image "10.0.0.5:5000/cnvrg/app:v9": rpc error: code = Unknown desc = Error response from daemon: Get "https://10.0.0.5:5000/v2/": dial tcp 10.0.0.5:5000: i/o timeout`,
+			want:    "timeout",
+		},
+		{
+			name:    "kubelet back-off",
+			message: `Back-off pulling image "cnvrg/app:v9"`,
+			want:    OTHER_CAUSE,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pullCause(tt.message); got != tt.want {
+				t.Errorf("pullCause() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
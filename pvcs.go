@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	pvcPage    = tview.NewFlex()
+	pvcForm    = tview.NewForm()
+	pvcTable   = tview.NewTable()
+	pvcHeaders = []string{"NAMESPACE", "NAME", "STATUS", "CAPACITY", "STORAGE CLASS", "ACCESS MODES", "VOLUME", "USED BY"}
+)
+
+var accessModes = map[corev1.PersistentVolumeAccessMode]string{
+	corev1.ReadWriteOnce:    "RWO",
+	corev1.ReadOnlyMany:     "ROX",
+	corev1.ReadWriteMany:    "RWX",
+	corev1.ReadWriteOncePod: "RWOP",
+}
+
+// Informers for the claims, the pods mounting them and their events in one
+// namespace.
+type pvcInformers struct {
+	claims cache.SharedIndexInformer
+	pods   cache.SharedIndexInformer
+	events cache.SharedIndexInformer
+}
+
+func (v *Versions) pvcInformers(namespaces []string) []pvcInformers {
+
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	var result []pvcInformers
+	for _, ns := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(&v.clientset, 0, informers.WithNamespace(ns))
+		result = append(result, pvcInformers{
+			claims: factory.Core().V1().PersistentVolumeClaims().Informer(),
+			pods:   factory.Core().V1().Pods().Informer(),
+			events: factory.Core().V1().Events().Informer(),
+		})
+	}
+	return result
+}
+
+// Returns the names of the pods mounting each claim, keyed by namespace/name.
+func claimUsers(pods []interface{}) map[string][]string {
+	users := map[string][]string{}
+	for _, obj := range pods {
+		p := obj.(*corev1.Pod)
+		for _, vol := range p.Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil {
+				key := p.Namespace + "/" + vol.PersistentVolumeClaim.ClaimName
+				users[key] = append(users[key], p.Name)
+			}
+		}
+	}
+	return users
+}
+
+func pvcRow(c *corev1.PersistentVolumeClaim, users []string) []string {
+
+	capacity := c.Status.Capacity[corev1.ResourceStorage]
+	if c.Status.Phase != corev1.ClaimBound {
+		capacity = c.Spec.Resources.Requests[corev1.ResourceStorage]
+	}
+
+	storageClass := "<default>"
+	if c.Spec.StorageClassName != nil {
+		storageClass = *c.Spec.StorageClassName
+	}
+
+	var modes []string
+	for _, m := range c.Spec.AccessModes {
+		modes = append(modes, accessModes[m])
+	}
+	sort.Strings(users)
+
+	return []string{
+		c.Namespace,
+		c.Name,
+		string(c.Status.Phase),
+		capacity.String(),
+		storageClass,
+		strings.Join(modes, ","),
+		c.Spec.VolumeName,
+		strings.Join(users, ","),
+	}
+}
+
+// Returns the grouped events of a claim.
+func claimEvents(events []interface{}, c *corev1.PersistentVolumeClaim) []*eventGroup {
+	var related []*corev1.Event
+	for _, obj := range events {
+		e := obj.(*corev1.Event)
+		if e.InvolvedObject.Kind == "PersistentVolumeClaim" && e.InvolvedObject.Name == c.Name && e.Namespace == c.Namespace {
+			related = append(related, e)
+		}
+	}
+	return aggregateEvents(related)
+}
+
+// Page listing the persistent volume claims of the cnvrg namespaces. Pending
+// claims are highlighted and their events are printed below the table until
+// a claim is selected, its events are then kept across refreshes.
+func pvcsMenu() {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	namespaces := strings.Join(v.namespaces(), ", ")
+	var watched []pvcInformers
+	var shown []*corev1.PersistentVolumeClaim
+
+	// The claim whose events are shown, namespace/name. The events of the
+	// pending claims are shown while none is selected.
+	selected := ""
+
+	events := func() []interface{} {
+		var all []interface{}
+		for _, w := range watched {
+			all = append(all, w.events.GetStore().List()...)
+		}
+		return all
+	}
+
+	showEvents := func(claims []*corev1.PersistentVolumeClaim) {
+		var lines []string
+		all := events()
+		for _, c := range claims {
+			lines = append(lines, c.Namespace+"/"+c.Name+" "+string(c.Status.Phase)+":")
+			for _, g := range claimEvents(all, c) {
+				lines = append(lines, fmt.Sprintf("  %s ago  %s  %s (x%d)  %s", formatAge(g.lastSeen), g.eventType, g.reason, g.count, g.message))
+			}
+		}
+		setText(strings.Join(lines, "\n"), "white")
+	}
+
+	render := func() {
+		var pods []interface{}
+		shown = nil
+		for _, w := range watched {
+			pods = append(pods, w.pods.GetStore().List()...)
+			for _, obj := range w.claims.GetStore().List() {
+				shown = append(shown, obj.(*corev1.PersistentVolumeClaim))
+			}
+		}
+		sort.Slice(shown, func(a, b int) bool {
+			return shown[a].Namespace+"/"+shown[a].Name < shown[b].Namespace+"/"+shown[b].Name
+		})
+		users := claimUsers(pods)
+
+		var pending []*corev1.PersistentVolumeClaim
+		pvcTable.Clear()
+		setTableHeader(pvcTable, pvcHeaders)
+		for n, c := range shown {
+			color := tcell.ColorWhite
+			switch c.Status.Phase {
+			case corev1.ClaimPending:
+				color = tcell.ColorYellow
+				pending = append(pending, c)
+			case corev1.ClaimLost:
+				color = tcell.ColorRed
+			}
+			for col, value := range pvcRow(c, users[c.Namespace+"/"+c.Name]) {
+				pvcTable.SetCell(n+1, col, tview.NewTableCell(value).SetTextColor(color))
+			}
+		}
+		pvcTable.SetTitle(fmt.Sprintf(" Persistent Volume Claims (%d, %d pending) ", len(shown), len(pending)))
+
+		for _, c := range shown {
+			if c.Namespace+"/"+c.Name == selected {
+				showEvents([]*corev1.PersistentVolumeClaim{c})
+				return
+			}
+		}
+		selected = ""
+		if len(pending) > 0 {
+			showEvents(pending)
+		}
+	}
+
+	watch := func() {
+		selected = ""
+		stop := startWatch()
+		watched = v.pvcInformers(splitList(namespaces))
+		var all []cache.SharedIndexInformer
+		for _, w := range watched {
+			all = append(all, w.claims, w.pods, w.events)
+		}
+		watchInformers(stop, render, all...)
+		render()
+	}
+
+	pvcForm.Clear(true)
+	pvcForm.SetHorizontal(true).
+		SetBorder(true).
+		SetTitle(" Persistent Volume Claims ").
+		SetTitleAlign(tview.AlignCenter).
+		SetTitleColor(tcell.ColorGreen)
+
+	pvcForm.AddInputField("Namespaces: ", namespaces, 40, nil, func(s string) {
+		namespaces = s
+	}).AddButton("Return to Tools", func() {
+		showTools()
+	}).AddButton("Apply", func() {
+		watch()
+		app.SetFocus(pvcTable)
+	})
+
+	pvcTable.SetBorder(true)
+	pvcTable.SetFixed(1, 0).
+		SetSelectable(true, false).
+		SetDoneFunc(func(key tcell.Key) {
+			app.SetFocus(pvcForm)
+		}).
+		SetSelectedFunc(func(row int, column int) {
+			if row > 0 && row <= len(shown) {
+				c := shown[row-1]
+				selected = c.Namespace + "/" + c.Name
+				showEvents([]*corev1.PersistentVolumeClaim{c})
+			}
+		})
+
+	pvcPage.Clear().
+		SetDirection(tview.FlexRow).
+		AddItem(pvcForm, 5, 0, false).
+		AddItem(pvcTable, 0, 1, true)
+
+	watch()
+	pages.AddAndSwitchToPage("PVCs", pvcPage, true)
+	app.SetFocus(pvcTable)
+	setText("Select a claim to show its events. Esc moves to the namespaces.", "white")
+}
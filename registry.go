@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Media types we accept when asking the registry for a manifest.
+var manifestTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+var challengeParams = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// Small client for the Docker Registry HTTP API V2. Only the calls the tool
+// needs are implemented, authentication is basic or bearer token.
+type registryClient struct {
+	server   string
+	username string
+	password string
+	token    string
+	client   *http.Client
+}
+
+type descriptor struct {
+	MediaType string    `json:"mediaType"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *platform `json:"platform,omitempty"`
+}
+
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// A manifest is either an image manifest (config and layers) or an index
+// (manifests) depending on the media type.
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Config    descriptor   `json:"config"`
+	Layers    []descriptor `json:"layers"`
+	Manifests []descriptor `json:"manifests"`
+	digest    string
+}
+
+func (m *manifest) isIndex() bool {
+	return len(m.Manifests) > 0
+}
+
+func newRegistryClient(server string, username string, password string) *registryClient {
+	return &registryClient{
+		server:   server,
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Docker Hub is addressed as docker.io but its API lives on another host.
+func (r *registryClient) baseURL() string {
+	s := r.server
+	if s == "" || s == "docker.io" || s == "index.docker.io" {
+		s = "registry-1.docker.io"
+	}
+	if !strings.HasPrefix(s, "http://") && !strings.HasPrefix(s, "https://") {
+		s = "https://" + s
+	}
+	return strings.TrimSuffix(s, "/")
+}
+
+// Sends the request, if the registry answers with a bearer challenge a token
+// is requested for the challenged scope and the request is sent once more.
+func (r *registryClient) do(method string, path string, accept []string) (*http.Response, error) {
+
+	resp, err := r.send(method, path, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if resp.StatusCode == http.StatusUnauthorized && strings.HasPrefix(strings.ToLower(challenge), "bearer") {
+		resp.Body.Close()
+		if err := r.fetchToken(challenge); err != nil {
+			return nil, err
+		}
+		return r.send(method, path, accept)
+	}
+	return resp, nil
+}
+
+func (r *registryClient) send(method string, path string, accept []string) (*http.Response, error) {
+
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL()+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range accept {
+		req.Header.Add("Accept", a)
+	}
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	} else if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	return r.client.Do(req)
+}
+
+// Sends a request with a body to a path or to an absolute URL, such as the
+// location of an upload. A body can not be sent twice, so the bearer token
+// has to be in place from an earlier request made with do.
+func (r *registryClient) sendBody(method string, target string, contentType string, body io.Reader, size int64) (*http.Response, error) {
+
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		target = r.baseURL() + target
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	} else if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	return r.client.Do(req)
+}
+
+func (r *registryClient) fetchToken(challenge string) error {
+
+	params := map[string]string{}
+	for _, m := range challengeParams.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	if params["realm"] == "" {
+		return fmt.Errorf("registry %s sent an auth challenge without a realm", r.server)
+	}
+
+	q := url.Values{}
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	if params["scope"] != "" {
+		q.Set("scope", params["scope"])
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("token request to %s failed: %s", params["realm"], resp.Status)
+	}
+
+	var t struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return err
+	}
+	r.token = t.Token
+	if r.token == "" {
+		r.token = t.AccessToken
+	}
+	return nil
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return fmt.Errorf("%s %s: %s", resp.Request.Method, resp.Request.URL, resp.Status)
+}
+
+// Fetches the manifest for repo at a tag or digest. The digest is taken from
+// the Docker-Content-Digest header, or computed when the registry omits it.
+func (r *registryClient) getManifest(repo string, ref string) (*manifest, error) {
+
+	resp, err := r.do(http.MethodGet, "/v2/"+repo+"/manifests/"+ref, manifestTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &manifest{}
+	if err := json.Unmarshal(body, m); err != nil {
+		return nil, err
+	}
+	if m.MediaType == "" {
+		m.MediaType = resp.Header.Get("Content-Type")
+	}
+	m.digest = resp.Header.Get("Docker-Content-Digest")
+	if m.digest == "" {
+		m.digest = fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	}
+	return m, nil
+}
+
+// Checks that a blob can be retrieved from the registry.
+func (r *registryClient) blobExists(repo string, digest string) error {
+
+	resp, err := r.do(http.MethodHead, "/v2/"+repo+"/blobs/"+digest, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return checkStatus(resp)
+}
+
+// Uploads a blob in a single request.
+func (r *registryClient) pushBlob(repo string, digest string, size int64, body io.Reader) error {
+
+	resp, err := r.do(http.MethodPost, "/v2/"+repo+"/blobs/uploads/", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return err
+	}
+	q := location.Query()
+	q.Set("digest", digest)
+	location.RawQuery = q.Encode()
+
+	resp, err = r.sendBody(http.MethodPut, location.String(), "application/octet-stream", body, size)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return checkStatus(resp)
+}
+
+// Mounts a blob pushed to another repository of the same registry.
+func (r *registryClient) mountBlob(repo string, digest string, from string) error {
+
+	resp, err := r.do(http.MethodPost, "/v2/"+repo+"/blobs/uploads/?mount="+url.QueryEscape(digest)+"&from="+url.QueryEscape(from), nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("registry %s did not mount %s from %s: %s", r.server, digest, from, resp.Status)
+	}
+	return nil
+}
+
+func (r *registryClient) putManifest(repo string, ref string, mediaType string, body []byte) error {
+
+	resp, err := r.sendBody(http.MethodPut, "/v2/"+repo+"/manifests/"+ref, mediaType, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return checkStatus(resp)
+}
+
+// Decodes a JSON response into v and returns the path of the next page when
+// the registry paginates with a Link header.
+func (r *registryClient) getJSON(path string, v interface{}) (string, error) {
+
+	resp, err := r.do(http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", err
+	}
+
+	link := resp.Header.Get("Link")
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start {
+		return "", nil
+	}
+	next, err := url.Parse(link[start+1 : end])
+	if err != nil {
+		return "", err
+	}
+	return next.RequestURI(), nil
+}
+
+// Lists the repositories in the registry, most public registries do not allow
+// the _catalog endpoint and will return an error.
+func (r *registryClient) catalog() ([]string, error) {
+
+	var repos []string
+	path := "/v2/_catalog?n=1000"
+	for path != "" {
+		var page struct {
+			Repositories []string `json:"repositories"`
+		}
+		next, err := r.getJSON(path, &page)
+		if err != nil {
+			return repos, err
+		}
+		repos = append(repos, page.Repositories...)
+		path = next
+	}
+	return repos, nil
+}
+
+// Lists the tags of a repository.
+func (r *registryClient) tags(repo string) ([]string, error) {
+
+	var tags []string
+	path := "/v2/" + repo + "/tags/list?n=1000"
+	for path != "" {
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		next, err := r.getJSON(path, &page)
+		if err != nil {
+			return tags, err
+		}
+		tags = append(tags, page.Tags...)
+		path = next
+	}
+	return tags, nil
+}
+
+// Image configuration blob, only the fields shown in the UI.
+type imageConfig struct {
+	Created      time.Time `json:"created"`
+	Architecture string    `json:"architecture"`
+	OS           string    `json:"os"`
+	Variant      string    `json:"variant,omitempty"`
+}
+
+func (r *registryClient) getConfig(repo string, digest string) (*imageConfig, error) {
+
+	c := &imageConfig{}
+	if _, err := r.getJSON("/v2/"+repo+"/blobs/"+digest, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
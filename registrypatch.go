@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// Time given to the operator to pick up the change when the custom resource
+// does not report the generation it reconciled.
+const RECONCILE_GRACE = 30 * time.Second
+
+// Registry settings of the cnvrg custom resource. The operator pulls every
+// image from imageHub and creates the pull secret named registry.name from the
+// registry credentials.
+type registrySettings struct {
+	imageHub    string
+	url         string
+	secret      string
+	username    string
+	password    string
+	credentials bool
+}
+
+// Returns the JSON merge patch setting the registry of the custom resource.
+func (s registrySettings) patch() ([]byte, error) {
+
+	registry := map[string]interface{}{"name": s.secret, "url": s.url}
+	if s.credentials {
+		registry["user"] = s.username
+		registry["password"] = s.password
+	}
+	return json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"imageHub": s.imageHub,
+			"registry": registry,
+		},
+	})
+}
+
+// The patch as it is previewed, with the password left out.
+func (s registrySettings) preview() string {
+	if s.credentials {
+		s.password = REDACTED
+	}
+	data, err := s.patch()
+	if err != nil {
+		return err.Error()
+	}
+	out, err := yaml.JSONToYAML(data)
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+// Reports whether the operator reconciled the current generation of the
+// custom resource. Operators report it differently, the observed generation,
+// a Ready condition and a status string are checked in that order.
+func crReconciled(obj *unstructured.Unstructured, since time.Time) (bool, string) {
+
+	if observed, found, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration"); found {
+		return observed >= obj.GetGeneration(), fmt.Sprintf("observed generation %d of %d", observed, obj.GetGeneration())
+	}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, _ := c.(map[string]interface{})
+		if cond["type"] != "Ready" {
+			continue
+		}
+		message := fmt.Sprintf("Ready=%v %v", cond["status"], cond["message"])
+		if cond["status"] != "True" {
+			return false, message
+		}
+		// The condition may still describe the previous generation
+		if observed, found, _ := unstructured.NestedInt64(cond, "observedGeneration"); found {
+			return observed >= obj.GetGeneration(), message
+		}
+		return time.Since(since) > RECONCILE_GRACE, message
+	}
+	if status, found, _ := unstructured.NestedString(obj.Object, "status", "status"); found {
+		ready := strings.EqualFold(status, "READY") || strings.EqualFold(status, "HEALTHY")
+		return ready && time.Since(since) > RECONCILE_GRACE, "status " + status
+	}
+	return time.Since(since) > RECONCILE_GRACE, "no reconcile status reported"
+}
+
+// Applies the patch, then follows the operator and the rollouts of the cnvrg
+// namespaces until everything is healthy or the timeout expires.
+func (v *Versions) applyRegistryPatch(dyn dynamic.Interface, r *customResource, patch []byte, timeout time.Duration) {
+	InfoLogger.Println("In the applyRegistryPatch function")
+
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	// The resource may have changed since the menu was opened
+	current, err := r.resource(dyn).Get(ctx, r.obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		panic(err)
+	}
+	applied, err := r.resource(dyn).Patch(ctx, r.obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		panic(err)
+	}
+	InfoLogger.Println("Patched the registry of", r.obj.GetKind(), r.obj.GetNamespace()+"/"+r.obj.GetName())
+	if applied.GetGeneration() == current.GetGeneration() {
+		setText("The custom resource already had these registry settings, nothing changed.", "green")
+		return
+	}
+
+	start := time.Now()
+	deadline := start.Add(timeout)
+	for {
+		obj, err := r.resource(dyn).Get(ctx, r.obj.GetName(), metav1.GetOptions{})
+		if err != nil {
+			panic(err)
+		}
+		reconciled, status := crReconciled(obj, start)
+		pending, failed, err := v.pendingRollouts(v.namespaces())
+		if err != nil {
+			panic(err)
+		}
+
+		lines := []string{
+			fmt.Sprintf("%s %s: %s (%s elapsed)", r.obj.GetKind(), r.obj.GetName(), status, time.Since(start).Round(time.Second)),
+		}
+		lines = append(lines, failed...)
+		lines = append(lines, pending...)
+
+		switch {
+		case reconciled && len(pending) == 0 && len(failed) == 0:
+			setText("The registry settings are applied and all workloads are healthy.\n"+strings.Join(lines, "\n"), "green")
+			return
+		case time.Now().After(deadline):
+			setText("Timed out waiting for the rollout:\n"+strings.Join(lines, "\n"), "red")
+			return
+		}
+		setText("Waiting for the operator and the rollouts...\n"+strings.Join(lines, "\n"), "white")
+		time.Sleep(3 * time.Second)
+	}
+}
+
+// Form to point the cnvrg custom resource at the private registry.
+func registryPatchMenu(i *Images) {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+	dyn, err := v.dynamicClient()
+	if err != nil {
+		handlePanic(err)
+		return
+	}
+	resources, err := v.customResources(dyn)
+	if err != nil {
+		handlePanic(err)
+		return
+	}
+	if len(resources) == 0 {
+		setText("No cnvrg custom resources were found in the cluster.", "red")
+		return
+	}
+
+	var names []string
+	selected := 0
+	for n, r := range resources {
+		names = append(names, r.obj.GetKind()+" "+r.obj.GetNamespace()+"/"+r.obj.GetName())
+		if r.obj.GetKind() == "CnvrgApp" && resources[selected].obj.GetKind() != "CnvrgApp" {
+			selected = n
+		}
+	}
+
+	s := registrySettings{
+		imageHub: i.target.name(),
+		url:      i.target.server,
+		secret:   DEFAULT_PULL_SECRET,
+		username: i.target.username,
+		password: i.target.password,
+	}
+	timeout := "600"
+
+	// Shows the patch and the resulting change of the resource from a dry run
+	preview := func() ([]byte, bool) {
+		patch, err := s.patch()
+		if err != nil {
+			handlePanic(err)
+			return nil, false
+		}
+		r := resources[selected]
+		result, err := r.resource(dyn).Patch(ctx, r.obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{DryRun: []string{metav1.DryRunAll}})
+		if err != nil {
+			setText("The API server rejected the patch:\n"+err.Error(), "red")
+			return nil, false
+		}
+		before, _ := yaml.Marshal(r.obj.Object["spec"])
+		after, _ := yaml.Marshal(result.Object["spec"])
+		diff := diffContext(lineDiff(string(before), string(after)), 2)
+		// The live resource may hold a password even without credentials
+		for n, line := range diff {
+			if strings.Contains(line, "password:") {
+				diff[n] = line[:strings.Index(line, "password:")] + "password: " + REDACTED
+			}
+		}
+		if len(diff) == 0 {
+			diff = []string{"  no changes"}
+		}
+		setText("Merge patch:\n"+s.preview()+"\nChanges to the spec:\n"+strings.Join(diff, "\n"), "white")
+		return patch, true
+	}
+
+	f := showToolForm("Registry Settings")
+	f.AddDropDown("Custom Resource: ", names, selected, func(option string, index int) {
+		selected = index
+	}).AddInputField("Image Hub: ", s.imageHub, 40, nil, func(text string) {
+		s.imageHub = text
+	}).AddInputField("Registry URL: ", s.url, 40, nil, func(text string) {
+		s.url = text
+	}).AddInputField("Pull Secret: ", s.secret, 40, nil, func(text string) {
+		s.secret = text
+	}).AddCheckbox("Include Credentials: ", s.credentials, func(checked bool) {
+		s.credentials = checked
+	}).AddInputField("Timeout (s): ", timeout, 10, nil, func(text string) {
+		timeout = text
+	}).AddButton("Preview", func() {
+		preview()
+	}).AddButton("Apply", func() {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil {
+			setText("Timeout must be a number.", "red")
+			return
+		}
+		patch, ok := preview()
+		if !ok {
+			return
+		}
+		r := resources[selected]
+		confirm("Patch the registry settings of "+r.obj.GetKind()+" "+r.obj.GetName()+"? The operator will roll out the cnvrg workloads.", func() {
+			go v.applyRegistryPatch(dyn, r, patch, time.Duration(seconds)*time.Second)
+		})
+	})
+
+	setText("Sets imageHub and registry in the custom resource with a JSON merge patch. The image hub and registry URL default to the push target, leave the credentials out when the pull secret exists already.", "white")
+}
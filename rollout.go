@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reports whether the rollout of a deployment finished, the same way kubectl
+// rollout status does. failed is set when the progress deadline was exceeded.
+func deploymentRollout(d *appsv1.Deployment) (done bool, failed bool, message string) {
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	name := "deployment/" + d.Namespace + "/" + d.Name
+
+	if d.Generation > d.Status.ObservedGeneration {
+		return false, false, name + ": waiting for the new spec to be observed"
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Reason == "ProgressDeadlineExceeded" {
+			return false, true, name + ": progress deadline exceeded, " + c.Message
+		}
+	}
+	switch {
+	case d.Status.UpdatedReplicas < replicas:
+		return false, false, fmt.Sprintf("%s: %d of %d replicas updated", name, d.Status.UpdatedReplicas, replicas)
+	case d.Status.Replicas > d.Status.UpdatedReplicas:
+		return false, false, fmt.Sprintf("%s: %d old replicas pending termination", name, d.Status.Replicas-d.Status.UpdatedReplicas)
+	case d.Status.AvailableReplicas < d.Status.UpdatedReplicas:
+		return false, false, fmt.Sprintf("%s: %d of %d updated replicas available", name, d.Status.AvailableReplicas, d.Status.UpdatedReplicas)
+	}
+	return true, false, fmt.Sprintf("%s: %d replicas available", name, d.Status.AvailableReplicas)
+}
+
+func statefulSetRollout(s *appsv1.StatefulSet) (done bool, message string) {
+
+	replicas := int32(1)
+	if s.Spec.Replicas != nil {
+		replicas = *s.Spec.Replicas
+	}
+	name := "statefulset/" + s.Namespace + "/" + s.Name
+
+	switch {
+	case s.Generation > s.Status.ObservedGeneration:
+		return false, name + ": waiting for the new spec to be observed"
+	case s.Status.UpdatedReplicas < replicas && s.Status.UpdateRevision != s.Status.CurrentRevision:
+		return false, fmt.Sprintf("%s: %d of %d replicas updated", name, s.Status.UpdatedReplicas, replicas)
+	case s.Status.ReadyReplicas < replicas:
+		return false, fmt.Sprintf("%s: %d of %d replicas ready", name, s.Status.ReadyReplicas, replicas)
+	}
+	return true, fmt.Sprintf("%s: %d replicas ready", name, s.Status.ReadyReplicas)
+}
+
+// Returns the deployments and statefulsets of the namespaces that are still
+// rolling out, and the ones whose rollout failed.
+func (v *Versions) pendingRollouts(namespaces []string) (pending []string, failed []string, err error) {
+
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	for _, ns := range namespaces {
+		deployments, err := v.clientset.AppsV1().Deployments(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		for n := range deployments.Items {
+			done, deadline, message := deploymentRollout(&deployments.Items[n])
+			switch {
+			case deadline:
+				failed = append(failed, message)
+			case !done:
+				pending = append(pending, message)
+			}
+		}
+
+		statefulSets, err := v.clientset.AppsV1().StatefulSets(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		for n := range statefulSets.Items {
+			if done, message := statefulSetRollout(&statefulSets.Items[n]); !done {
+				pending = append(pending, message)
+			}
+		}
+
+		// Pods that can not pull their images never become ready
+		pods, err := v.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, p := range pods.Items {
+			for _, c := range p.Status.ContainerStatuses {
+				if c.State.Waiting != nil && pullFailures[c.State.Waiting.Reason] {
+					failed = append(failed, fmt.Sprintf("pod/%s/%s: %s %s", p.Namespace, p.Name, c.State.Waiting.Reason, c.Image))
+				}
+			}
+		}
+	}
+	return pending, failed, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const DEFAULT_PULL_SECRET = "cnvrg-registry"
+
+// Builds the .dockerconfigjson content for the registry of the target.
+func dockerConfigJSON(t *Target) ([]byte, error) {
+
+	auth := base64.StdEncoding.EncodeToString([]byte(t.username + ":" + t.password))
+	config := map[string]interface{}{
+		"auths": map[string]interface{}{
+			t.server: map[string]string{
+				"username": t.username,
+				"password": t.password,
+				"auth":     auth,
+			},
+		},
+	}
+	return json.Marshal(config)
+}
+
+// Creates or updates the pull secret in every namespace, and when patchSA is
+// set adds it to the imagePullSecrets of the default service account. With
+// dryRun the API server validates the changes without persisting them.
+// Returns one line per namespace describing what changed.
+func (v *Versions) applyPullSecret(name string, namespaces []string, t *Target, patchSA bool, dryRun bool) []string {
+
+	var report []string
+	var dry []string
+	prefix := ""
+	if dryRun {
+		dry = []string{metav1.DryRunAll}
+		prefix = "(dry run) "
+	}
+
+	config, err := dockerConfigJSON(t)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	for _, ns := range namespaces {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ns,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "cnvrg-dep-tool"},
+			},
+			Type: corev1.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{corev1.DockerConfigJsonKey: config},
+		}
+
+		secrets := v.clientset.CoreV1().Secrets(ns)
+		existing, err := secrets.Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case errors.IsNotFound(err):
+			_, err = secrets.Create(ctx, secret, metav1.CreateOptions{DryRun: dry})
+			report = append(report, reportLine(prefix, ns, "secret "+name+" created", err))
+		case err != nil:
+			report = append(report, reportLine(prefix, ns, "", err))
+			continue
+		case existing.Type != secret.Type:
+			report = append(report, reportLine(prefix, ns, "", fmt.Errorf("secret %s exists with type %s", name, existing.Type)))
+			continue
+		case bytes.Equal(existing.Data[corev1.DockerConfigJsonKey], config):
+			report = append(report, reportLine(prefix, ns, "secret "+name+" unchanged", nil))
+		default:
+			existing.Data = secret.Data
+			_, err = secrets.Update(ctx, existing, metav1.UpdateOptions{DryRun: dry})
+			report = append(report, reportLine(prefix, ns, "secret "+name+" updated", err))
+		}
+
+		if patchSA {
+			report = append(report, v.addPullSecretToServiceAccount(ns, name, dry, prefix))
+		}
+	}
+	return report
+}
+
+func (v *Versions) addPullSecretToServiceAccount(ns string, name string, dry []string, prefix string) string {
+
+	accounts := v.clientset.CoreV1().ServiceAccounts(ns)
+	sa, err := accounts.Get(ctx, "default", metav1.GetOptions{})
+	if err != nil {
+		return reportLine(prefix, ns, "", err)
+	}
+
+	for _, ref := range sa.ImagePullSecrets {
+		if ref.Name == name {
+			return reportLine(prefix, ns, "service account default already references "+name, nil)
+		}
+	}
+
+	sa.ImagePullSecrets = append(sa.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+	_, err = accounts.Update(ctx, sa, metav1.UpdateOptions{DryRun: dry})
+	return reportLine(prefix, ns, "service account default now references "+name, err)
+}
+
+func reportLine(prefix string, ns string, change string, err error) string {
+	if err != nil {
+		return prefix + ns + ": ERROR " + err.Error()
+	}
+	return prefix + ns + ": " + change
+}
+
+// Form for the pull secret name and the namespaces it is created in. The
+// credentials are the ones entered in the push menu.
+func pullSecretMenu(i *Images) {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	name := DEFAULT_PULL_SECRET
+	namespaces := strings.Join(v.namespaces(), ", ")
+	patchSA := false
+
+	apply := func(dryRun bool) {
+		if i.target.username == "" {
+			setText("Please enter the private registry credentials in the Push Images menu first.", "red")
+			return
+		}
+		report := v.applyPullSecret(name, splitList(namespaces), &i.target, patchSA, dryRun)
+		color := "green"
+		if strings.Contains(strings.Join(report, "\n"), ": ERROR ") {
+			color = "red"
+		}
+		setText(strings.Join(report, "\n"), color)
+	}
+
+	f := showToolForm("Image Pull Secret")
+	f.AddInputField("Secret Name: ", name, 40, nil, func(s string) {
+		name = s
+	}).AddInputField("Namespaces: ", namespaces, 40, nil, func(s string) {
+		namespaces = s
+	}).AddCheckbox("Patch default service accounts: ", false, func(checked bool) {
+		patchSA = checked
+	}).AddButton("Dry Run", func() {
+		apply(true)
+	}).AddButton("Apply", func() {
+		apply(false)
+	})
+
+	setText(fmt.Sprintf("Creates a pull secret for %s from the credentials entered in the Push Images menu.", i.target.server), "white")
+}
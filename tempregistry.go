@@ -0,0 +1,577 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/distribution/reference"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+const (
+	TEMP_REGISTRY_NAME     = "cnvrg-temp-registry"
+	TEMP_REGISTRY_PORT     = 5000
+	DEFAULT_REGISTRY_IMAGE = "registry:2"
+	DEFAULT_BUNDLE_FILE    = "images.tar.gz"
+
+	OCI_MANIFEST = "application/vnd.oci.image.manifest.v1+json"
+	OCI_CONFIG   = "application/vnd.oci.image.config.v1+json"
+	OCI_LAYER    = "application/vnd.oci.image.layer.v1.tar"
+
+	OCI_LAYER_GZIP = "application/vnd.oci.image.layer.v1.tar+gzip"
+	OCI_LAYER_ZSTD = "application/vnd.oci.image.layer.v1.tar+zstd"
+)
+
+// An entry of manifest.json in a docker save archive.
+type savedImage struct {
+	Config   string
+	RepoTags []string
+	Layers   []string
+}
+
+type ociManifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        descriptor   `json:"config"`
+	Layers        []descriptor `json:"layers"`
+}
+
+// Files of a docker save archive, found in a first pass over it.
+type savedBundle struct {
+	images []savedImage
+	blobs  map[string]descriptor // by file name
+	paths  map[string]string     // file name by digest
+}
+
+// Opens a docker save archive. saveImages writes a plain tar even though the
+// file is named .tar.gz, so gzip is only used when the file starts with its
+// magic number.
+func openBundle(fileName string) (*tar.Reader, io.Closer, error) {
+
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := bufio.NewReader(f)
+	if magic, err := r.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return tar.NewReader(gz), f, nil
+	}
+	return tar.NewReader(r), f, nil
+}
+
+// Returns the layer media type matching the magic number of a blob. docker
+// save writes plain tar layers, the containerd image store keeps the
+// compressed layers of the registry.
+func layerMediaType(magic []byte) string {
+	switch {
+	case len(magic) >= 2 && magic[0] == 0x1f && magic[1] == 0x8b:
+		return OCI_LAYER_GZIP
+	case len(magic) >= 4 && magic[0] == 0x28 && magic[1] == 0xb5 && magic[2] == 0x2f && magic[3] == 0xfd:
+		return OCI_LAYER_ZSTD
+	}
+	return OCI_LAYER
+}
+
+// Reads the manifest of the archive and the digest and layer media type of
+// every file. Layers that appear in several images are stored once and linked
+// from the others.
+func scanBundle(fileName string) (*savedBundle, error) {
+
+	tr, closer, err := openBundle(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	b := &savedBundle{blobs: map[string]descriptor{}, paths: map[string]string{}}
+	links := map[string]string{}
+	var manifestJSON []byte
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeSymlink:
+			links[hdr.Name] = path.Join(path.Dir(hdr.Name), hdr.Linkname)
+		case tar.TypeReg:
+			if hdr.Name == "manifest.json" {
+				if manifestJSON, err = io.ReadAll(tr); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			br := bufio.NewReader(tr)
+			magic, _ := br.Peek(4)
+			mediaType := layerMediaType(magic)
+			h := sha256.New()
+			size, err := io.Copy(h, br)
+			if err != nil {
+				return nil, err
+			}
+			digest := fmt.Sprintf("sha256:%x", h.Sum(nil))
+			b.blobs[hdr.Name] = descriptor{MediaType: mediaType, Digest: digest, Size: size}
+			b.paths[digest] = hdr.Name
+		}
+	}
+
+	for name, target := range links {
+		if d, ok := b.blobs[target]; ok {
+			b.blobs[name] = d
+		}
+	}
+	if manifestJSON == nil {
+		return nil, fmt.Errorf("%s has no manifest.json, it is not a docker save archive", fileName)
+	}
+	if err := json.Unmarshal(manifestJSON, &b.images); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Pushes every tagged image of the archive to the registry as an OCI image,
+// the layers keep the compression they have in the archive.
+func pushBundle(r *registryClient, fileName string) ([]string, error) {
+
+	setText("Reading "+fileName+"...", "white")
+	b, err := scanBundle(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	type pushRef struct {
+		repo, tag string
+		manifest  []byte
+	}
+	var refs []pushRef
+
+	// Repositories needing each blob, the first one gets the upload and the
+	// others mount it
+	needed := map[string][]string{}
+
+	for _, img := range b.images {
+		config, ok := b.blobs[img.Config]
+		if !ok {
+			return nil, fmt.Errorf("config %s is missing from %s", img.Config, fileName)
+		}
+		m := ociManifest{SchemaVersion: 2, MediaType: OCI_MANIFEST, Config: descriptor{MediaType: OCI_CONFIG, Digest: config.Digest, Size: config.Size}}
+		blobs := []string{config.Digest}
+		for _, l := range img.Layers {
+			layer, ok := b.blobs[l]
+			if !ok {
+				return nil, fmt.Errorf("layer %s is missing from %s", l, fileName)
+			}
+			m.Layers = append(m.Layers, layer)
+			blobs = append(blobs, layer.Digest)
+		}
+		body, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range img.RepoTags {
+			named, err := reference.ParseNormalizedNamed(t)
+			if err != nil {
+				return nil, err
+			}
+			named = reference.TagNameOnly(named)
+			repo := reference.Path(named)
+			refs = append(refs, pushRef{repo: repo, tag: refVersion(named), manifest: body})
+			for _, digest := range blobs {
+				if !containsString(needed[digest], repo) && r.blobExists(repo, digest) != nil {
+					needed[digest] = append(needed[digest], repo)
+				}
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("%s has no tagged images", fileName)
+	}
+
+	// Second pass uploading the blobs in the order they are stored
+	tr, closer, err := openBundle(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	uploaded := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		d, ok := b.blobs[hdr.Name]
+		if !ok || hdr.Typeflag != tar.TypeReg || b.paths[d.Digest] != hdr.Name || len(needed[d.Digest]) == 0 {
+			continue
+		}
+		repos := needed[d.Digest]
+		uploaded++
+		setText(fmt.Sprintf("Uploading blob %d of %d to %s: %s (%.1f MB)", uploaded, len(needed), repos[0], d.Digest, float64(d.Size)/1e6), "white")
+		if err := r.pushBlob(repos[0], d.Digest, d.Size, tr); err != nil {
+			return nil, err
+		}
+		for _, repo := range repos[1:] {
+			if err := r.mountBlob(repo, d.Digest, repos[0]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var pushed []string
+	for _, ref := range refs {
+		if err := r.putManifest(ref.repo, ref.tag, OCI_MANIFEST, ref.manifest); err != nil {
+			return pushed, err
+		}
+		pushed = append(pushed, ref.repo+":"+ref.tag)
+	}
+	return pushed, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the claim, deployment and service of the temporary registry.
+func tempRegistryObjects(namespace string, image string, size resource.Quantity, storageClass string) (*corev1.PersistentVolumeClaim, *appsv1.Deployment, *corev1.Service) {
+
+	podLabels := map[string]string{"app": TEMP_REGISTRY_NAME}
+	meta := metav1.ObjectMeta{Name: TEMP_REGISTRY_NAME, Namespace: namespace, Labels: podLabels}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: meta,
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+
+	replicas := int32(1)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: meta,
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: podLabels},
+			// The claim can only be mounted by one pod at a time
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: podLabels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:  "registry",
+						Image: image,
+						Ports: []corev1.ContainerPort{{ContainerPort: TEMP_REGISTRY_PORT}},
+						Env: []corev1.EnvVar{
+							{Name: "REGISTRY_STORAGE_DELETE_ENABLED", Value: "true"},
+						},
+						VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: "/var/lib/registry"}},
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{Path: "/v2/", Port: intstr.FromInt32(TEMP_REGISTRY_PORT)},
+							},
+						},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "data",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: TEMP_REGISTRY_NAME},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	// A NodePort lets the container runtime of the nodes reach the registry
+	// without cluster DNS
+	svc := &corev1.Service{
+		ObjectMeta: meta,
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeNodePort,
+			Selector: podLabels,
+			Ports: []corev1.ServicePort{{
+				Name:       "registry",
+				Port:       TEMP_REGISTRY_PORT,
+				TargetPort: intstr.FromInt32(TEMP_REGISTRY_PORT),
+			}},
+		},
+	}
+	return pvc, deploy, svc
+}
+
+// Creates the registry objects, the ones that exist already are kept.
+func (v *Versions) createTempRegistry(pvc *corev1.PersistentVolumeClaim, deploy *appsv1.Deployment, svc *corev1.Service) error {
+
+	ns := deploy.Namespace
+	if _, err := v.clientset.CoreV1().PersistentVolumeClaims(ns).Create(ctx, pvc, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	if _, err := v.clientset.AppsV1().Deployments(ns).Create(ctx, deploy, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	if _, err := v.clientset.CoreV1().Services(ns).Create(ctx, svc, metav1.CreateOptions{}); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// Waits until the registry deployment is available and returns its ready pod.
+func (v *Versions) waitTempRegistry(ns string, timeout time.Duration) (string, error) {
+
+	deadline := time.Now().Add(timeout)
+	for {
+		d, err := v.clientset.AppsV1().Deployments(ns).Get(ctx, TEMP_REGISTRY_NAME, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		done, failed, message := deploymentRollout(d)
+		if failed {
+			return "", fmt.Errorf("%s", message)
+		}
+		if done && d.Status.AvailableReplicas > 0 {
+			pods, err := v.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{LabelSelector: "app=" + TEMP_REGISTRY_NAME})
+			if err != nil {
+				return "", err
+			}
+			for _, p := range pods.Items {
+				if p.Status.Phase == corev1.PodRunning && p.DeletionTimestamp == nil {
+					return p.Name, nil
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out waiting for the registry: %s", message)
+		}
+		setText("Waiting for the registry to start: "+message, "white")
+		time.Sleep(3 * time.Second)
+	}
+}
+
+// Forwards a free local port to the registry pod until stop is closed and
+// returns the local port.
+func (v *Versions) forwardRegistry(ns string, pod string, stop chan struct{}) (uint16, error) {
+
+	config, err := kubeconfig.ClientConfig()
+	if err != nil {
+		return 0, err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return 0, err
+	}
+	req := v.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(ns).Name(pod).SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	ready := make(chan struct{})
+	fw, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", TEMP_REGISTRY_PORT)}, stop, ready, io.Discard, io.Discard)
+	if err != nil {
+		return 0, err
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- fw.ForwardPorts()
+	}()
+	select {
+	case <-ready:
+	case err := <-errs:
+		return 0, err
+	}
+	ports, err := fw.GetPorts()
+	if err != nil {
+		return 0, err
+	}
+	return ports[0].Local, nil
+}
+
+// Returns the addresses the nodes can pull from. The container runtime
+// resolves names with the DNS of the node, so the service name is left out.
+func (v *Versions) tempRegistryAddresses(ns string) ([]string, error) {
+
+	svc, err := v.clientset.CoreV1().Services(ns).Get(ctx, TEMP_REGISTRY_NAME, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	addresses := []string{fmt.Sprintf("%s:%d (cluster IP)", svc.Spec.ClusterIP, TEMP_REGISTRY_PORT)}
+
+	node := "<node address>"
+	if nodes, err := v.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err == nil && len(nodes.Items) > 0 {
+		for _, a := range nodes.Items[0].Status.Addresses {
+			if a.Type == corev1.NodeInternalIP {
+				node = a.Address
+				break
+			}
+		}
+	}
+	for _, p := range svc.Spec.Ports {
+		if p.NodePort != 0 {
+			addresses = append(addresses, fmt.Sprintf("%s:%d (node port, any node address works)", node, p.NodePort))
+		}
+	}
+	return addresses, nil
+}
+
+// Deploys the registry, pushes the bundle through a port-forward and prints
+// the addresses to use in the cnvrg configuration.
+func (v *Versions) deployTempRegistry(pvc *corev1.PersistentVolumeClaim, deploy *appsv1.Deployment, svc *corev1.Service, bundle string) {
+	InfoLogger.Println("In the deployTempRegistry function")
+
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	ns := deploy.Namespace
+	setText("Creating the registry in "+ns+"...", "white")
+	if err := v.createTempRegistry(pvc, deploy, svc); err != nil {
+		panic(err)
+	}
+	pod, err := v.waitTempRegistry(ns, 10*time.Minute)
+	if err != nil {
+		panic(err)
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	port, err := v.forwardRegistry(ns, pod, stop)
+	if err != nil {
+		panic(err)
+	}
+	InfoLogger.Printf("Forwarding 127.0.0.1:%d to %s/%s", port, ns, pod)
+
+	r := newRegistryClient(fmt.Sprintf("http://127.0.0.1:%d", port), "", "")
+	// Layers are large and the tunnel is slow, the default timeout is too short
+	r.client = &http.Client{}
+	pushed, err := pushBundle(r, bundle)
+	if err != nil {
+		panic(err)
+	}
+
+	addresses, err := v.tempRegistryAddresses(ns)
+	if err != nil {
+		panic(err)
+	}
+	lines := []string{fmt.Sprintf("Pushed %d images from %s:", len(pushed), bundle)}
+	for _, p := range pushed {
+		lines = append(lines, "  "+p)
+	}
+	lines = append(lines, "", "Registry addresses for the cnvrg configuration:")
+	for _, a := range addresses {
+		lines = append(lines, "  "+a)
+	}
+	lines = append(lines, "", "The registry serves plain HTTP, the container runtime of the nodes must allow it as an insecure registry.")
+	setText(strings.Join(lines, "\n"), "green")
+}
+
+// Deletes the registry and its stored images.
+func (v *Versions) teardownTempRegistry(ns string) {
+
+	background := metav1.DeletePropagationBackground
+	opts := metav1.DeleteOptions{PropagationPolicy: &background}
+	errs := []error{
+		v.clientset.CoreV1().Services(ns).Delete(ctx, TEMP_REGISTRY_NAME, opts),
+		v.clientset.AppsV1().Deployments(ns).Delete(ctx, TEMP_REGISTRY_NAME, opts),
+		v.clientset.CoreV1().PersistentVolumeClaims(ns).Delete(ctx, TEMP_REGISTRY_NAME, opts),
+	}
+
+	var lines []string
+	color := "green"
+	for n, kind := range []string{"service", "deployment", "persistentvolumeclaim"} {
+		line := fmt.Sprintf("%s %s/%s: deleted", kind, ns, TEMP_REGISTRY_NAME)
+		switch {
+		case errors.IsNotFound(errs[n]):
+			line = fmt.Sprintf("%s %s/%s: not found", kind, ns, TEMP_REGISTRY_NAME)
+		case errs[n] != nil:
+			line = fmt.Sprintf("%s %s/%s: ERROR %v", kind, ns, TEMP_REGISTRY_NAME, errs[n])
+			color = "red"
+		}
+		lines = append(lines, line)
+	}
+	InfoLogger.Println("Removed the temporary registry from", ns)
+	setText(strings.Join(lines, "\n"), color)
+}
+
+// Form to run a registry in the cluster and fill it from a saved bundle.
+func tempRegistryMenu() {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	namespace, image := v.appNamespace(), DEFAULT_REGISTRY_IMAGE
+	size, storageClass, bundle := "100Gi", "", DEFAULT_BUNDLE_FILE
+
+	f := showToolForm("In-cluster Registry")
+	f.AddInputField("Namespace: ", namespace, 40, nil, func(s string) {
+		namespace = s
+	}).AddInputField("Registry Image: ", image, 40, nil, func(s string) {
+		image = s
+	}).AddInputField("Storage Size: ", size, 10, nil, func(s string) {
+		size = s
+	}).AddInputField("Storage Class: ", storageClass, 40, nil, func(s string) {
+		storageClass = s
+	}).AddInputField("Bundle File: ", bundle, 40, nil, func(s string) {
+		bundle = s
+	}).AddButton("Deploy and Push", func() {
+		quantity, err := resource.ParseQuantity(size)
+		if err != nil {
+			setText("Storage Size: "+err.Error(), "red")
+			return
+		}
+		if _, err := os.Stat(bundle); err != nil {
+			handlePanic(err)
+			return
+		}
+		pvc, deploy, svc := tempRegistryObjects(namespace, image, quantity, storageClass)
+		go v.deployTempRegistry(pvc, deploy, svc, bundle)
+	}).AddButton("Teardown", func() {
+		confirm("Delete the registry in "+namespace+" and the images stored in it?", func() {
+			v.teardownTempRegistry(namespace)
+		})
+	})
+
+	setText("Runs a registry with a persistent volume in the cluster and pushes the images saved with Save Images through a port-forward. The registry image must be pullable by the nodes, for example loaded on them beforehand.", "white")
+}
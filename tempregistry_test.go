@@ -0,0 +1,146 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// A file of a test archive, a symlink when link is set.
+type bundleFile struct {
+	name string
+	data []byte
+	link string
+}
+
+func writeBundle(t *testing.T, files []bundleFile, compress bool) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.data)), Typeflag: tar.TypeReg}
+		if f.link != "" {
+			hdr = &tar.Header{Name: f.name, Mode: 0777, Linkname: f.link, Typeflag: tar.TypeSymlink}
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	if compress {
+		data = gzipped(t, data)
+	}
+	fileName := filepath.Join(t.TempDir(), "images.tar.gz")
+	if err := os.WriteFile(fileName, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return fileName
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func blobDescriptor(mediaType string, data []byte) descriptor {
+	return descriptor{MediaType: mediaType, Digest: fmt.Sprintf("sha256:%x", sha256.Sum256(data)), Size: int64(len(data))}
+}
+
+func TestScanBundle(t *testing.T) {
+
+	config := []byte(`{"architecture":"amd64","os":"linux"}`)
+	layer := []byte("plain tar layer")
+	compressed := gzipped(t, []byte("compressed layer"))
+	zstd := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}
+	manifest := []byte(`[{"Config":"blobs/sha256/config","RepoTags":["cnvrg/app:v5"],"Layers":["blobs/sha256/layer","blobs/sha256/gzip","blobs/sha256/zstd","app/layer.tar"]}]`)
+
+	files := []bundleFile{
+		{name: "blobs/sha256/config", data: config},
+		{name: "blobs/sha256/layer", data: layer},
+		{name: "blobs/sha256/gzip", data: compressed},
+		{name: "blobs/sha256/zstd", data: zstd},
+		{name: "app/layer.tar", link: "../blobs/sha256/layer"},
+		{name: "manifest.json", data: manifest},
+	}
+
+	tests := []struct {
+		name     string
+		files    []bundleFile
+		compress bool
+		want     map[string]descriptor
+		wantErr  bool
+	}{
+		{
+			name:  "plain tar",
+			files: files,
+			want: map[string]descriptor{
+				"blobs/sha256/config": blobDescriptor(OCI_LAYER, config),
+				"blobs/sha256/layer":  blobDescriptor(OCI_LAYER, layer),
+				"blobs/sha256/gzip":   blobDescriptor(OCI_LAYER_GZIP, compressed),
+				"blobs/sha256/zstd":   blobDescriptor(OCI_LAYER_ZSTD, zstd),
+				"app/layer.tar":       blobDescriptor(OCI_LAYER, layer),
+			},
+		},
+		{
+			name:    "missing manifest",
+			files:   files[:2],
+			wantErr: true,
+		},
+		{
+			name:     "gzip archive",
+			files:    append(append([]bundleFile{}, files[:2]...), bundleFile{name: "manifest.json", data: manifest}),
+			compress: true,
+			want: map[string]descriptor{
+				"blobs/sha256/config": blobDescriptor(OCI_LAYER, config),
+				"blobs/sha256/layer":  blobDescriptor(OCI_LAYER, layer),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := scanBundle(writeBundle(t, tt.files, tt.compress))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error for an archive without manifest.json")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(b.blobs, tt.want) {
+				t.Errorf("blobs = %v, want %v", b.blobs, tt.want)
+			}
+			if len(b.images) != 1 || !reflect.DeepEqual(b.images[0].RepoTags, []string{"cnvrg/app:v5"}) {
+				t.Errorf("images = %v", b.images)
+			}
+			if name := b.paths[blobDescriptor(OCI_LAYER, layer).Digest]; name != "blobs/sha256/layer" && name != "app/layer.tar" {
+				t.Errorf("path of the layer = %q", name)
+			}
+		})
+	}
+}
@@ -18,12 +18,14 @@ var (
 		SetChangedFunc(func() {
 			app.Draw()
 		})
-	pages   = tview.NewPages()
-	flex    = tview.NewFlex()
-	form    = tview.NewForm()
-	menu    = tview.NewForm()
-	start   = tview.NewForm()
-	topText = tview.NewTextView()
+	pages    = tview.NewPages()
+	flex     = tview.NewFlex()
+	form     = tview.NewForm()
+	menu     = tview.NewForm()
+	start    = tview.NewForm()
+	tools    = tview.NewList()
+	toolForm = tview.NewForm()
+	topText  = tview.NewTextView()
 
 	DEFAULT_USERNAME = "cnvrghelm"
 )
@@ -36,6 +38,8 @@ func runTview() {
 		AddPage("View", text, true, false).
 		AddPage("Push", form, true, false).
 		AddPage("StartMenu", start, true, false).
+		AddPage("Tools", tools, true, false).
+		AddPage("ToolForm", toolForm, true, false).
 		SetBorder(true)
 
 	flex.AddItem(topText, 0, 1, true).
@@ -59,7 +63,10 @@ func runTview() {
 	i := Images{}
 	mainMenu(&i)
 
-	if err := app.SetRoot(flex, true).EnableMouse(true).Run(); err != nil {
+	err := app.SetRoot(flex, true).EnableMouse(true).Run()
+	// Leaves no pre-warm daemonset behind on the nodes
+	stopPrewarm(true)
+	if err != nil {
 		log.Println(err)
 		panic(err)
 	}
@@ -121,13 +128,101 @@ func mainMenu(i *Images) {
 	}).AddButton("Save Images to TAR", func() {
 		s := i.saveImages()
 		setText(s, "green")
+	}).AddButton("Tools", func() {
+		showTools()
 	})
 
+	toolsMenu(i)
+}
+
+// List of the registry and cluster tools, each item opens its own page
+func toolsMenu(i *Images) {
+
+	tools.Clear()
+	tools.SetBorder(true).
+		SetTitle(" Tools ").
+		SetTitleAlign(tview.AlignCenter).
+		SetTitleColor(tcell.ColorGreen)
+
+	tools.AddItem("Return to Main Menu", "", 0, func() {
+		pages.SwitchToPage("Menu")
+		app.SetFocus(menu)
+	}).AddItem("Browse Registry", "List repositories, tags and manifests in the private registry", 0, func() {
+		registryBrowser(i)
+	}).AddItem("Generate Image Overrides", "Write Helm, kustomize or CSV image mappings for the private registry", 0, func() {
+		overridesMenu(i)
+	}).AddItem("Create Pull Secret", "Create or update an image pull secret in the cnvrg namespaces", 0, func() {
+		pullSecretMenu(i)
+	}).AddItem("Export Cluster Images", "Write the images running in the cluster to an images file", 0, func() {
+		exportMenu()
+	}).AddItem("Extract Images From Manifests", "Write the images referenced by Kubernetes or Helm manifests on disk", 0, func() {
+		extractMenu()
+	}).AddItem("cnvrg Components", "Change the deployments shown in the version panel", 0, func() {
+		componentsMenu()
+	}).AddItem("Kube Contexts", "Switch cluster or load another kubeconfig file", 0, func() {
+		contextMenu()
+	}).AddItem("Multi-cluster Overview", "Compare cnvrg versions across kube contexts", 0, func() {
+		overviewMenu()
+	}).AddItem("Nodes", "Cluster nodes with their resources, conditions and taints", 0, func() {
+		nodesMenu()
+	}).AddItem("Pods", "Pods of the cnvrg namespaces with live status", 0, func() {
+		podsMenu()
+	}).AddItem("Events", "Events of the cnvrg namespaces, grouped and filtered", 0, func() {
+		eventsMenu()
+	}).AddItem("Persistent Volume Claims", "Claims of the cnvrg namespaces with the pods mounting them", 0, func() {
+		pvcsMenu()
+	}).AddItem("Collect Support Bundle", "Gather cnvrg diagnostics into a tar.gz for the vendor", 0, func() {
+		bundleMenu(i)
+	}).AddItem("Preflight Checks", "Check that the cluster is ready for a cnvrg installation", 0, func() {
+		preflightMenu()
+	}).AddItem("Cluster Pull Check", "Pull the pushed images from pods in the cluster", 0, func() {
+		pullCheckMenu(i)
+	}).AddItem("cnvrg Custom Resources", "View and edit the cnvrg custom resources", 0, func() {
+		customResourcesMenu()
+	}).AddItem("Registry Settings", "Point the cnvrg custom resource at the private registry", 0, func() {
+		registryPatchMenu(i)
+	}).AddItem("Upgrade cnvrg", "Set a new image tag on the operator and app", 0, func() {
+		upgradeMenu()
+	}).AddItem("Image Pull Failures", "Find images the cluster fails to pull", 0, func() {
+		pullFailuresMenu(i)
+	}).AddItem("Image Drift Report", "Compare the cluster, the images file and the registry", 0, func() {
+		driftMenu(i)
+	}).AddItem("Pre-warm Node Images", "Pull the images on every node ahead of scheduling", 0, func() {
+		prewarmMenu(i)
+	}).AddItem("In-cluster Registry", "Run a registry in the cluster and push a saved bundle to it", 0, func() {
+		tempRegistryMenu()
+	})
+}
+
+func showTools() {
+	stopWatch()
+	pages.SwitchToPage("Tools")
+	app.SetFocus(tools)
+}
+
+// Clears the shared tool form and switches to it, the caller adds the fields
+// and buttons of the tool
+func showToolForm(title string) *tview.Form {
+
+	toolForm.Clear(true)
+	toolForm.SetInputCapture(nil)
+	toolForm.SetBorder(true).
+		SetTitle(" " + title + " ").
+		SetTitleAlign(tview.AlignCenter).
+		SetTitleColor(tcell.ColorGreen)
+
+	toolForm.AddButton("Return to Tools", func() {
+		showTools()
+	})
+
+	pages.SwitchToPage("ToolForm")
+	app.SetFocus(toolForm)
+	return toolForm
 }
 
 // removed s []string from this function
 func pushMenu(i *Images, s []string) {
-	i.server = "docker.io"
+	i.target = Target{server: "docker.io", rewrite: DEFAULT_REWRITE}
 
 	form.SetBorder(true).
 		SetTitle(" cnvrg.io Deployment Tool ").
@@ -139,14 +234,17 @@ func pushMenu(i *Images, s []string) {
 		SetTextColor(tcell.ColorWhite)
 
 	form.AddInputField("Docker Username: ", "", 40, nil, func(user string) {
-		i.username = user
+		i.target.username = user
 	}).AddPasswordField("Docker Password: ", "", 40, 42, func(password string) {
-		i.password = password
+		i.target.password = password
 	}).AddInputField("Server Address: ", "docker.io", 40, nil, func(server string) {
-		i.server = server
+		i.target.server = server
 	}).AddInputField("Registry: ", "", 40, nil, func(registry string) {
-		i.registry = registry
+		i.target.registry = registry
+	}).AddInputField("Tag Rewrite: ", DEFAULT_REWRITE, 40, nil, func(rewrite string) {
+		i.target.rewrite = rewrite
 	}).AddButton("Return to Main Menu", func() {
+		i.targets = nil
 		form.Clear(true)
 		pages.SwitchToPage("Menu")
 		app.SetFocus(menu)
@@ -160,6 +258,11 @@ func pushMenu(i *Images, s []string) {
 	}).AddButton("List Images", func() {
 		text.Clear()
 		setText(i.listImages(), "white")
+	}).AddButton("Verify Push", func() {
+		text.Clear()
+		go i.verifyPush()
+	}).AddButton("Add Target", func() {
+		i.addTarget()
 	})
 }
 
@@ -199,6 +302,26 @@ func setTopText(s string, c string) {
 
 }
 
+// Shows a dialog over the current page, yes is called when the action is
+// confirmed. Focus returns to the previous primitive either way.
+func confirm(message string, yes func()) {
+
+	focus := app.GetFocus()
+	modal := tview.NewModal().
+		SetText(message).
+		AddButtons([]string{"Cancel", "Confirm"}).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			pages.RemovePage("Confirm")
+			app.SetFocus(focus)
+			if buttonLabel == "Confirm" {
+				yes()
+			}
+		})
+
+	pages.AddPage("Confirm", modal, false, true)
+	app.SetFocus(modal)
+}
+
 func updateText(s []string, e error) {
 
 	if e != nil {
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	REVISION_ANNOTATION     = "deployment.kubernetes.io/revision"
+	CHANGE_CAUSE_ANNOTATION = "kubernetes.io/change-cause"
+)
+
+// Closed to stop following the rollout. It is not tied to the informer pages,
+// only the upgrade page starts and stops it.
+var rolloutStop chan struct{}
+
+// Stops following the previous rollout and returns the stop channel for the
+// next one.
+func startRolloutWatch() chan struct{} {
+	stopRolloutWatch()
+	rolloutStop = make(chan struct{})
+	return rolloutStop
+}
+
+func stopRolloutWatch() {
+	if rolloutStop != nil {
+		close(rolloutStop)
+		rolloutStop = nil
+	}
+}
+
+// Sets the tag of the first container image of a deployment, the container
+// the version panel reads. Returns the previous image.
+func (v *Versions) setDeploymentTag(ns string, name string, tag string) (string, error) {
+
+	previous := ""
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		d, err := v.clientset.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if len(d.Spec.Template.Spec.Containers) == 0 {
+			return fmt.Errorf("deployment %s/%s has no containers", ns, name)
+		}
+		previous = d.Spec.Template.Spec.Containers[0].Image
+		image := withImageTag(previous, tag)
+		d.Spec.Template.Spec.Containers[0].Image = image
+		if d.Annotations == nil {
+			d.Annotations = map[string]string{}
+		}
+		d.Annotations[CHANGE_CAUSE_ANNOTATION] = "cnvrg-dep-tool upgrade to " + image
+		_, err = v.clientset.AppsV1().Deployments(ns).Update(ctx, d, metav1.UpdateOptions{})
+		return err
+	})
+	return previous, err
+}
+
+// Rolls a deployment back to the pod template of its previous ReplicaSet
+// revision, like kubectl rollout undo. Returns the revision rolled back to.
+func (v *Versions) rollbackDeployment(ns string, name string) (int64, error) {
+
+	revision := int64(0)
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		d, err := v.clientset.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		current, err := strconv.ParseInt(d.Annotations[REVISION_ANNOTATION], 10, 64)
+		if err != nil {
+			return fmt.Errorf("deployment %s/%s has no revision yet", ns, name)
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+		if err != nil {
+			return err
+		}
+		list, err := v.clientset.AppsV1().ReplicaSets(ns).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return err
+		}
+
+		var previous *appsv1.ReplicaSet
+		revision = 0
+		for n, rs := range list.Items {
+			if !metav1.IsControlledBy(&rs, d) {
+				continue
+			}
+			r, err := strconv.ParseInt(rs.Annotations[REVISION_ANNOTATION], 10, 64)
+			if err == nil && r < current && r > revision {
+				previous, revision = &list.Items[n], r
+			}
+		}
+		if previous == nil {
+			return fmt.Errorf("deployment %s/%s has no revision before %d", ns, name, current)
+		}
+
+		template := previous.Spec.Template.DeepCopy()
+		delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+		d.Spec.Template = *template
+		d.Annotations[CHANGE_CAUSE_ANNOTATION] = fmt.Sprintf("cnvrg-dep-tool rollback to revision %d", revision)
+		_, err = v.clientset.AppsV1().Deployments(ns).Update(ctx, d, metav1.UpdateOptions{})
+		return err
+	})
+	return revision, err
+}
+
+// Returns how long the deployment has left to make progress before the
+// rollout is considered failed.
+func progressDeadline(d *appsv1.Deployment) string {
+	if d.Spec.ProgressDeadlineSeconds == nil {
+		return ""
+	}
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing {
+			left := c.LastUpdateTime.Add(time.Duration(*d.Spec.ProgressDeadlineSeconds) * time.Second).Sub(time.Now())
+			return fmt.Sprintf("progress deadline in %s", left.Round(time.Second))
+		}
+	}
+	return ""
+}
+
+// Polls the rollouts of the deployments until they finish, one fails or stop
+// is closed.
+func (v *Versions) watchRollouts(stop chan struct{}, deployments []*appsv1.Deployment, action string) {
+	InfoLogger.Println("In the watchRollouts function")
+
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	start := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		lines := []string{fmt.Sprintf("%s, %s elapsed", action, time.Since(start).Round(time.Second))}
+		finished, failed := 0, false
+		for _, ref := range deployments {
+			d, err := v.clientset.AppsV1().Deployments(ref.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+			if err != nil {
+				panic(err)
+			}
+			done, deadline, message := deploymentRollout(d)
+			replicas := fmt.Sprintf("  updated %d, available %d, unavailable %d", d.Status.UpdatedReplicas, d.Status.AvailableReplicas, d.Status.UnavailableReplicas)
+			if !done && !deadline {
+				replicas += "   " + progressDeadline(d)
+			}
+			lines = append(lines, message, replicas)
+			if done {
+				finished++
+			}
+			failed = failed || deadline
+		}
+
+		switch {
+		case failed:
+			lines = append(lines, "", "The rollout failed. Press Ctrl-R to roll back to the previous revision.")
+			setText(strings.Join(lines, "\n"), "red")
+			return
+		case finished == len(deployments):
+			setText(strings.Join(lines, "\n"), "green")
+			cluster.getVersions()
+			return
+		}
+		setText(strings.Join(lines, "\n"), "white")
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// Form to upgrade the operator, and optionally the app, to a new image tag.
+func upgradeMenu() {
+
+	v := requireCluster()
+	if v == nil {
+		return
+	}
+
+	statuses := v.componentStatuses(ctx)
+	var current []string
+	for _, c := range statuses {
+		current = append(current, c.String())
+	}
+
+	tag, withApp := "", false
+	var upgraded []*appsv1.Deployment
+
+	rollback := func() {
+		if len(upgraded) == 0 {
+			setText("Nothing was upgraded yet.", "red")
+			return
+		}
+		var lines []string
+		for _, d := range upgraded {
+			revision, err := v.rollbackDeployment(d.Namespace, d.Name)
+			if err != nil {
+				handlePanic(err)
+				return
+			}
+			lines = append(lines, fmt.Sprintf("Rolled back %s/%s to revision %d", d.Namespace, d.Name, revision))
+		}
+		InfoLogger.Println(strings.Join(lines, ", "))
+		go v.watchRollouts(startRolloutWatch(), upgraded, strings.Join(lines, "\n"))
+	}
+
+	f := showToolForm("Upgrade cnvrg")
+	f.AddInputField("New Tag: ", tag, 40, nil, func(s string) {
+		tag = s
+	}).AddCheckbox("Also Upgrade App: ", withApp, func(checked bool) {
+		withApp = checked
+	}).AddButton("Upgrade", func() {
+		if tag == "" {
+			setText("Please enter the new tag.", "red")
+			return
+		}
+
+		// The operator is upgraded first so it does not revert the app
+		targets := []componentStatus{statuses[1]}
+		if withApp {
+			targets = append(targets, statuses[0])
+		}
+		var names []string
+		for _, c := range targets {
+			if !c.found {
+				setText(c.String(), "red")
+				return
+			}
+			names = append(names, fmt.Sprintf("%s/%s (%s -> %s)", c.namespace, c.name, c.version(), tag))
+		}
+
+		confirm("Upgrade "+strings.Join(names, " and ")+"?", func() {
+			upgraded = nil
+			for _, c := range targets {
+				previous, err := v.setDeploymentTag(c.namespace, c.name, tag)
+				if err != nil {
+					handlePanic(err)
+					return
+				}
+				InfoLogger.Printf("Upgraded %s/%s from %s to tag %s", c.namespace, c.name, previous, tag)
+				upgraded = append(upgraded, &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: c.namespace, Name: c.name}})
+			}
+			go v.watchRollouts(startRolloutWatch(), upgraded, "Upgrading to "+tag)
+		})
+	}).AddButton("Roll Back", func() {
+		rollback()
+	}).AddButton("Stop Watching", func() {
+		stopRolloutWatch()
+		setText("Stopped following the rollout, the deployments keep rolling out.", "white")
+	})
+
+	f.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyCtrlR {
+			rollback()
+			return nil
+		}
+		return event
+	})
+
+	setText(strings.Join(current, "\n")+"\n\nThe rollout is followed live once the upgrade starts, Ctrl-R rolls the upgraded deployments back to their previous revision.", "white")
+}
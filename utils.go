@@ -8,6 +8,8 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
+	"time"
 )
 
 func readFile(f string) ([]string, error) {
@@ -21,9 +23,14 @@ func readFile(f string) ([]string, error) {
 
 	scanner := bufio.NewScanner(file)
 
+	// Blank lines and comments are skipped, an image may be followed by a
+	// comment on the same line
 	var images []string
 	for scanner.Scan() {
-		images = append(images, scanner.Text())
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			images = append(images, line)
+		}
 	}
 	if err := scanner.Err(); err != nil {
 		log.Fatal(err)
@@ -31,6 +38,96 @@ func readFile(f string) ([]string, error) {
 	return images, err
 }
 
+// Splits a comma separated list, surrounding spaces and empty items are
+// dropped.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Returns the environment variable key, or def when it is not set.
+func envOr(key string, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// Compares two texts line by line. Unchanged lines start with two spaces,
+// removed lines with "- " and added lines with "+ ". An empty text has no
+// lines.
+func lineDiff(a string, b string) []string {
+
+	split := func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return strings.Split(s, "\n")
+	}
+	x, y := split(a), split(b)
+
+	// Longest common subsequence of the lines from the end of both texts
+	lcs := make([][]int, len(x)+1)
+	for n := range lcs {
+		lcs[n] = make([]int, len(y)+1)
+	}
+	for n := len(x) - 1; n >= 0; n-- {
+		for m := len(y) - 1; m >= 0; m-- {
+			if x[n] == y[m] {
+				lcs[n][m] = lcs[n+1][m+1] + 1
+			} else {
+				lcs[n][m] = max(lcs[n+1][m], lcs[n][m+1])
+			}
+		}
+	}
+
+	var diff []string
+	n, m := 0, 0
+	for n < len(x) || m < len(y) {
+		switch {
+		case n < len(x) && m < len(y) && x[n] == y[m]:
+			diff = append(diff, "  "+x[n])
+			n, m = n+1, m+1
+		case n < len(x) && (m == len(y) || lcs[n+1][m] >= lcs[n][m+1]):
+			diff = append(diff, "- "+x[n])
+			n++
+		default:
+			diff = append(diff, "+ "+y[m])
+			m++
+		}
+	}
+	return diff
+}
+
+// Returns the changed lines of a diff with the given number of unchanged lines
+// around them.
+func diffContext(diff []string, context int) []string {
+
+	var lines []string
+	last := -1
+	for n, line := range diff {
+		if strings.HasPrefix(line, "  ") {
+			continue
+		}
+		from := max(n-context, last+1)
+		if last >= 0 && from > last+1 {
+			lines = append(lines, "...")
+		}
+		for k := from; k <= min(n+context, len(diff)-1); k++ {
+			if k > last {
+				lines = append(lines, diff[k])
+				last = k
+			}
+		}
+	}
+	return lines
+}
+
 func utilsErrorHandling(error interface{}) {
 	ErrorLogger.Println(error)
 	handlePanic(fmt.Sprint(error))
@@ -148,3 +245,22 @@ func addToArchive(tw *tar.Writer, filename string) error {
 
 	return nil
 }
+
+// Writes data to the tar archive as a file called name
+func addBytesToArchive(tw *tar.Writer, name string, data []byte) error {
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+
+	err := tw.WriteHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = tw.Write(data)
+	return err
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want []string
+	}{
+		{
+			name: "unchanged",
+			a:    "a\nb",
+			b:    "a\nb",
+			want: []string{"  a", "  b"},
+		},
+		{
+			name: "insert",
+			a:    "a\nc",
+			b:    "a\nb\nc",
+			want: []string{"  a", "+ b", "  c"},
+		},
+		{
+			name: "delete",
+			a:    "a\nb\nc",
+			b:    "a\nc",
+			want: []string{"  a", "- b", "  c"},
+		},
+		{
+			name: "replace",
+			a:    "a\nb\nc",
+			b:    "a\nx\nc",
+			want: []string{"  a", "- b", "+ x", "  c"},
+		},
+		{
+			name: "both empty",
+			a:    "",
+			b:    "",
+			want: nil,
+		},
+		{
+			name: "from empty",
+			a:    "",
+			b:    "a\nb",
+			want: []string{"+ a", "+ b"},
+		},
+		{
+			name: "to empty",
+			a:    "a",
+			b:    "",
+			want: []string{"- a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lineDiff(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lineDiff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffContext(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		diff    []string
+		context int
+		want    []string
+	}{
+		{
+			name:    "no changes",
+			diff:    []string{"  a", "  b"},
+			context: 2,
+			want:    nil,
+		},
+		{
+			name:    "trims unchanged lines",
+			diff:    []string{"  a", "  b", "  c", "- d", "+ x", "  e", "  f", "  g"},
+			context: 1,
+			want:    []string{"  c", "- d", "+ x", "  e"},
+		},
+		{
+			name:    "separates distant changes",
+			diff:    []string{"- a", "  b", "  c", "  d", "  e", "+ f"},
+			context: 1,
+			want:    []string{"- a", "  b", "...", "  e", "+ f"},
+		},
+		{
+			name:    "joins overlapping context",
+			diff:    []string{"- a", "  b", "  c", "+ d"},
+			context: 1,
+			want:    []string{"- a", "  b", "  c", "+ d"},
+		},
+		{
+			name:    "without context",
+			diff:    []string{"  a", "- b", "  c"},
+			context: 0,
+			want:    []string{"- b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffContext(tt.diff, tt.context); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffContext() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/distribution/reference"
+)
+
+const VERIFY_REPORT_PATH = "verify-report.json"
+
+// Result of checking a single pushed image against the registry.
+type verifyResult struct {
+	Target       string   `json:"target"`
+	Image        string   `json:"image"`
+	RemoteDigest string   `json:"remoteDigest,omitempty"`
+	LocalDigest  string   `json:"localDigest,omitempty"`
+	Blobs        int      `json:"blobs"`
+	MissingBlobs []string `json:"missingBlobs,omitempty"`
+	Passed       bool     `json:"passed"`
+	Error        string   `json:"error,omitempty"`
+}
+
+// Queries the target registries for every tagged image, checks that the
+// manifest and all blobs it references can be retrieved and that the digest
+// matches the local image. The report is printed to the UI and written to a
+// JSON file.
+func (i *Images) verifyPush() []verifyResult {
+	InfoLogger.Println("In the verifyPush function")
+
+	defer func() {
+		if err := recover(); err != nil {
+			ErrorLogger.Println(err)
+			handlePanic(err)
+		}
+	}()
+
+	var results []verifyResult
+
+	for _, t := range i.activeTargets() {
+		if t.tag == nil {
+			results = append(results, verifyResult{Target: t.name(), Error: "no tagged images"})
+			continue
+		}
+
+		clients := map[string]*registryClient{}
+		for _, v := range t.tag {
+			res := verifyResult{Target: t.name(), Image: v}
+
+			if reason, ok := t.failure(v); ok {
+				res.Error = "push failed: " + reason
+				results = append(results, res)
+				continue
+			}
+
+			named, err := reference.ParseNormalizedNamed(v)
+			if err != nil {
+				res.Error = err.Error()
+				results = append(results, res)
+				continue
+			}
+			domain := reference.Domain(named)
+			if clients[domain] == nil {
+				clients[domain] = newRegistryClient(domain, t.username, t.password)
+			}
+			verifyImage(clients[domain], named, &res)
+			results = append(results, res)
+		}
+	}
+
+	report, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile(VERIFY_REPORT_PATH, report, 0644); err != nil {
+		panic(err)
+	}
+
+	var lines []string
+	color := "green"
+	for _, r := range results {
+		if r.Passed {
+			lines = append(lines, fmt.Sprintf("PASS [%s] %s %s", r.Target, r.Image, r.RemoteDigest))
+			continue
+		}
+		color = "red"
+		lines = append(lines, fmt.Sprintf("FAIL [%s] %s: %s", r.Target, r.Image, r.Error))
+	}
+	lines = append(lines, "", "Report written to "+VERIFY_REPORT_PATH)
+	setText(strings.Join(lines, "\n"), color)
+
+	return results
+}
+
+func verifyImage(r *registryClient, named reference.Named, res *verifyResult) {
+
+	repo := reference.Path(named)
+	ref := "latest"
+	if tagged, ok := named.(reference.Tagged); ok {
+		ref = tagged.Tag()
+	}
+
+	m, err := r.getManifest(repo, ref)
+	if err != nil {
+		res.Error = err.Error()
+		return
+	}
+	res.RemoteDigest = m.digest
+
+	// An index only points at other manifests, check each of them.
+	manifests := []*manifest{m}
+	if m.isIndex() {
+		manifests = nil
+		for _, d := range m.Manifests {
+			child, err := r.getManifest(repo, d.Digest)
+			if err != nil {
+				res.Error = err.Error()
+				return
+			}
+			manifests = append(manifests, child)
+		}
+	}
+
+	for _, mf := range manifests {
+		blobs := append([]descriptor{mf.Config}, mf.Layers...)
+		for _, b := range blobs {
+			res.Blobs++
+			if err := r.blobExists(repo, b.Digest); err != nil {
+				ErrorLogger.Println(err)
+				res.MissingBlobs = append(res.MissingBlobs, b.Digest)
+			}
+		}
+	}
+	if len(res.MissingBlobs) > 0 {
+		res.Error = fmt.Sprintf("%d of %d blobs are missing", len(res.MissingBlobs), res.Blobs)
+		return
+	}
+
+	res.LocalDigest = localDigest(named)
+	if res.LocalDigest == "" {
+		res.Error = "no local repo digest found for this image"
+		return
+	}
+	if res.LocalDigest != res.RemoteDigest {
+		res.Error = "local digest " + res.LocalDigest + " does not match registry digest " + res.RemoteDigest
+		return
+	}
+	res.Passed = true
+}
+
+// Returns the digest docker recorded for the image when it was pushed to the
+// repository of named, or an empty string if there is none.
+func localDigest(named reference.Named) string {
+
+	inspect, _, err := cli.ImageInspectWithRaw(ctx, named.String())
+	if err != nil {
+		ErrorLogger.Println(err)
+		return ""
+	}
+
+	for _, rd := range inspect.RepoDigests {
+		parsed, err := reference.ParseNormalizedNamed(rd)
+		if err != nil {
+			continue
+		}
+		if canonical, ok := parsed.(reference.Canonical); ok && parsed.Name() == named.Name() {
+			return canonical.Digest().String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Closed when the page fed by informers is left
+var watchStop chan struct{}
+
+// Stops the informers of the previous page and returns the stop channel for
+// the informers of the next one.
+func startWatch() chan struct{} {
+	stopWatch()
+	watchStop = make(chan struct{})
+	return watchStop
+}
+
+func stopWatch() {
+	if watchStop != nil {
+		close(watchStop)
+		watchStop = nil
+	}
+}
+
+// Runs the informers until stop is closed and calls changed on the UI thread
+// after adds, updates and deletes. Bursts of events are redrawn at most twice
+// a second.
+func watchInformers(stop chan struct{}, changed func(), informers ...cache.SharedIndexInformer) {
+
+	pending := make(chan struct{}, 1)
+	notify := func(obj interface{}) {
+		select {
+		case pending <- struct{}{}:
+		default:
+		}
+	}
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    notify,
+		UpdateFunc: func(old interface{}, obj interface{}) { notify(obj) },
+		DeleteFunc: notify,
+	}
+
+	for _, informer := range informers {
+		informer.AddEventHandler(handler)
+		go informer.Run(stop)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-pending:
+				app.QueueUpdateDraw(changed)
+				time.Sleep(500 * time.Millisecond)
+			}
+		}
+	}()
+}
+
+// Replaces the first row of the table with the column headers.
+func setTableHeader(table *tview.Table, headers []string) {
+	for col, h := range headers {
+		table.SetCell(0, col, tview.NewTableCell(h).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+}